@@ -0,0 +1,411 @@
+package cronyx
+
+import (
+	"context"
+	"fmt"
+)
+
+// ConcurrencyPolicy decides what happens when a job is due to run while
+// an earlier run of the same job ID is still running.
+type ConcurrencyPolicy string
+
+const (
+	// ConcurrencyAllow runs overlapping runs side by side (the default).
+	ConcurrencyAllow ConcurrencyPolicy = ""
+	// ConcurrencySkip records the new run as RunSkipped, also when the
+	// earlier run hasn't started yet because it waits for a label limit.
+	ConcurrencySkip ConcurrencyPolicy = "skip"
+	// ConcurrencyQueueOne holds the new run until the running one ends.
+	// At most one run waits; further runs are skipped.
+	ConcurrencyQueueOne ConcurrencyPolicy = "queue-one"
+	// ConcurrencyReplace cancels the running run as soon as the new one
+	// is queued, and starts the new one once it has stopped. A waiting run
+	// is replaced by a newer one. Replaced runs are RunCancelled.
+	ConcurrencyReplace ConcurrencyPolicy = "replace"
+)
+
+func (p ConcurrencyPolicy) valid() bool {
+	switch p {
+	case ConcurrencyAllow, ConcurrencySkip, ConcurrencyQueueOne, ConcurrencyReplace:
+		return true
+	}
+	return false
+}
+
+// activeRun is a run that has been admitted by the concurrency limits.
+type activeRun struct {
+	qr     queuedRun
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+}
+
+// admit applies the job's concurrency policy and the label limits to a
+// run taken off the queue. Runs that have to wait are held until a run
+// ends. It returns the next run the worker should execute, which is the
+// oldest held run that may start and not necessarily qr, or nil. ok is
+// false once the engine is stopped; qr is then abandoned.
+func (e *Engine) admit(qr queuedRun) (next *activeRun, ok bool) {
+	e.runsMu.Lock()
+	// under runsMu, so that CancelRun finds qr either queued or held
+	if e.dequeued(qr) {
+		e.runsMu.Unlock()
+		e.recordUnstarted(qr, RunCancelled, ErrRunCancelled.Error())
+		return nil, true
+	}
+	select {
+	case <-e.stopCh:
+		e.runsMu.Unlock()
+		e.abandon(qr)
+		return nil, false
+	default:
+	}
+
+	skipped, reason := e.applyPolicyLocked(qr)
+	if skipped == nil || skipped.runID != qr.runID {
+		e.held = append(e.held, qr)
+	}
+	next = e.takeHeldLocked()
+	e.runsMu.Unlock()
+
+	if skipped != nil {
+		e.recordUnstarted(*skipped, RunSkipped, reason)
+	}
+	e.wakeWorkers()
+	return next, true
+}
+
+// applyPolicyLocked applies the job's concurrency policy to qr before it
+// is held. It returns the run the policy skips, which is qr itself or a
+// run it replaces, and why. runsMu must be held.
+func (e *Engine) applyPolicyLocked(qr queuedRun) (*queuedRun, string) {
+	id := qr.job.ID
+	running := len(e.active[id]) > 0
+	held := e.heldIndexLocked(id)
+
+	switch qr.job.Concurrency {
+	case ConcurrencySkip:
+		if running {
+			return &qr, "previous run still running"
+		}
+		if held >= 0 {
+			return &qr, "previous run still waiting to start"
+		}
+	case ConcurrencyQueueOne:
+		if held >= 0 {
+			return &qr, "a run of the job is already waiting"
+		}
+	case ConcurrencyReplace:
+		e.replaceActiveLocked(qr)
+		if held >= 0 {
+			old := e.held[held]
+			e.held = append(e.held[:held], e.held[held+1:]...)
+			return &old, fmt.Sprintf("replaced by run %s", qr.runID)
+		}
+	}
+	return nil, ""
+}
+
+// admitInline admits a run executed by the calling goroutine rather than
+// a worker, see TestExecuteRun. It applies the same policy and label
+// limits as admit and waits while the run is held. The run is skipped,
+// cancelled or, once Shutdown starts, abandoned with an error when it
+// can't start. A started run is counted in workerWG; the caller must call
+// workerWG.Done when it ends.
+func (e *Engine) admitInline(ctx context.Context, qr queuedRun) (*activeRun, error) {
+	e.runsMu.Lock()
+	select {
+	case <-e.stopCh:
+		e.runsMu.Unlock()
+		return nil, ErrEngineStopped
+	default:
+	}
+	skipped, reason := e.applyPolicyLocked(qr)
+	if skipped != nil && skipped.runID == qr.runID {
+		e.runsMu.Unlock()
+		e.recordUnstarted(qr, RunSkipped, reason)
+		return nil, fmt.Errorf("%w: %s", ErrRunSkipped, reason)
+	}
+	e.held = append(e.held, qr)
+	e.runsMu.Unlock()
+	if skipped != nil {
+		e.recordUnstarted(*skipped, RunSkipped, reason)
+	}
+
+	e.runsMu.Lock()
+	for {
+		i := e.heldIndexByRunLocked(qr.runID)
+		if i < 0 {
+			// taken off the held list by CancelRun, CancelJob or Shutdown,
+			// which also recorded the run
+			e.runsMu.Unlock()
+			select {
+			case <-e.stopCh:
+				return nil, ErrEngineStopped
+			default:
+				return nil, ErrRunCancelled
+			}
+		}
+		if e.mayStartLocked(qr) {
+			select {
+			case <-e.stopCh:
+				// Shutdown may already be waiting on workerWG
+				e.held = append(e.held[:i], e.held[i+1:]...)
+				e.runsMu.Unlock()
+				e.abandon(qr)
+				return nil, ErrEngineStopped
+			default:
+			}
+			// counted like a worker run so that Shutdown waits for it;
+			// see the barrier in Shutdown
+			e.workerWG.Add(1)
+			ar := e.startLocked(i)
+			e.runsMu.Unlock()
+			return ar, nil
+		}
+		changed := e.changed
+		e.runsMu.Unlock()
+
+		select {
+		case <-changed:
+			e.runsMu.Lock()
+		case <-e.stopCh:
+			e.runsMu.Lock()
+			if i := e.heldIndexByRunLocked(qr.runID); i >= 0 {
+				e.held = append(e.held[:i], e.held[i+1:]...)
+				e.runsMu.Unlock()
+				e.abandon(qr)
+				return nil, ErrEngineStopped
+			}
+		case <-ctx.Done():
+			e.runsMu.Lock()
+			if i := e.heldIndexByRunLocked(qr.runID); i >= 0 {
+				e.held = append(e.held[:i], e.held[i+1:]...)
+				e.runsMu.Unlock()
+				e.recordUnstarted(qr, RunCancelled, ctx.Err().Error())
+				return nil, ctx.Err()
+			}
+		}
+	}
+}
+
+// CancelRun cancels a run by ID. A queued or waiting run is recorded as
+// RunCancelled without starting; a running run has its context cancelled
+// with cause ErrRunCancelled and is recorded as RunCancelled once its
+// stages return. Runs that aren't queued, waiting or running yield
+// ErrRunNotFound.
+func (e *Engine) CancelRun(runID string) error {
+	e.queueMu.Lock()
+	for _, ids := range e.queued {
+		for _, id := range ids {
+			if id == runID {
+				e.cancel[runID] = true
+				e.queueMu.Unlock()
+				return nil
+			}
+		}
+	}
+	e.queueMu.Unlock()
+
+	e.runsMu.Lock()
+	for _, runs := range e.active {
+		if ar, ok := runs[runID]; ok {
+			e.runsMu.Unlock()
+			ar.cancel(ErrRunCancelled)
+			return nil
+		}
+	}
+	for i, qr := range e.held {
+		if qr.runID == runID {
+			e.held = append(e.held[:i], e.held[i+1:]...)
+			e.runsMu.Unlock()
+			e.recordUnstarted(qr, RunCancelled, ErrRunCancelled.Error())
+			e.wakeWorkers()
+			return nil
+		}
+	}
+	e.runsMu.Unlock()
+	return fmt.Errorf("%w: %q", ErrRunNotFound, runID)
+}
+
+// CancelJob cancels every queued, waiting and running run of a job, as
+// CancelRun does, and returns how many runs it cancelled. The job stays
+// scheduled; use PauseJob or RemoveJob to stop future runs.
+func (e *Engine) CancelJob(jobID string) int {
+	n := 0
+	e.queueMu.Lock()
+	for _, id := range e.queued[jobID] {
+		if !e.cancel[id] {
+			e.cancel[id] = true
+			n++
+		}
+	}
+	e.queueMu.Unlock()
+
+	e.runsMu.Lock()
+	for _, ar := range e.active[jobID] {
+		if ar.ctx.Err() == nil {
+			ar.cancel(ErrRunCancelled)
+			n++
+		}
+	}
+	var cancelled []queuedRun
+	kept := e.held[:0]
+	for _, qr := range e.held {
+		if qr.job.ID == jobID {
+			cancelled = append(cancelled, qr)
+		} else {
+			kept = append(kept, qr)
+		}
+	}
+	e.held = kept
+	e.runsMu.Unlock()
+
+	for _, qr := range cancelled {
+		e.recordUnstarted(qr, RunCancelled, ErrRunCancelled.Error())
+	}
+	e.wakeWorkers()
+	return n + len(cancelled)
+}
+
+// replaceActive cancels the running runs of qr's job when it uses
+// ConcurrencyReplace. It is called as soon as qr is queued, so that the
+// old run doesn't hold a worker the new run is waiting for.
+func (e *Engine) replaceActive(qr queuedRun) {
+	if qr.job.Concurrency != ConcurrencyReplace {
+		return
+	}
+	e.runsMu.Lock()
+	defer e.runsMu.Unlock()
+	e.replaceActiveLocked(qr)
+}
+
+func (e *Engine) replaceActiveLocked(qr queuedRun) {
+	for _, ar := range e.active[qr.job.ID] {
+		if ar.ctx.Err() == nil {
+			e.logger.Printf("cronyx: job %s: cancelling run %s, replaced by run %s", qr.job.ID, ar.qr.runID, qr.runID)
+			ar.cancel(fmt.Errorf("%w: replaced by run %s", ErrRunCancelled, qr.runID))
+		}
+	}
+}
+
+// takeHeld starts the oldest held run that may run now, if any.
+func (e *Engine) takeHeld() *activeRun {
+	e.runsMu.Lock()
+	defer e.runsMu.Unlock()
+	return e.takeHeldLocked()
+}
+
+func (e *Engine) takeHeldLocked() *activeRun {
+	for i, qr := range e.held {
+		if !qr.inline && e.mayStartLocked(qr) {
+			return e.startLocked(i)
+		}
+	}
+	return nil
+}
+
+// mayStartLocked reports whether the job's policy and the label limits
+// let qr start now.
+func (e *Engine) mayStartLocked(qr queuedRun) bool {
+	if qr.job.Concurrency != ConcurrencyAllow && len(e.active[qr.job.ID]) > 0 {
+		return false
+	}
+	return e.labelsFreeLocked(qr.job)
+}
+
+// startLocked moves the held run at index i to the active runs.
+func (e *Engine) startLocked(i int) *activeRun {
+	qr := e.held[i]
+	e.held = append(e.held[:i], e.held[i+1:]...)
+	e.releaseSlot(qr)
+
+	ctx, cancel := context.WithCancelCause(e.runCtx)
+	ar := &activeRun{qr: qr, ctx: ctx, cancel: cancel}
+	if e.active[qr.job.ID] == nil {
+		e.active[qr.job.ID] = map[string]*activeRun{}
+	}
+	e.active[qr.job.ID][qr.runID] = ar
+	for _, key := range e.limitedLabels(qr.job) {
+		e.labelRuns[key]++
+	}
+	return ar
+}
+
+// release ends an admitted run and wakes idle workers for held runs that
+// may be able to start now.
+func (e *Engine) release(ar *activeRun) {
+	ar.cancel(nil)
+	e.runsMu.Lock()
+	id := ar.qr.job.ID
+	delete(e.active[id], ar.qr.runID)
+	if len(e.active[id]) == 0 {
+		delete(e.active, id)
+	}
+	for _, key := range e.limitedLabels(ar.qr.job) {
+		e.labelRuns[key]--
+	}
+	e.runsMu.Unlock()
+	e.wakeWorkers()
+}
+
+// wakeWorkers nudges idle workers, and runs waiting in admitInline, to
+// look at the held runs.
+func (e *Engine) wakeWorkers() {
+	e.runsMu.Lock()
+	n := len(e.held)
+	close(e.changed)
+	e.changed = make(chan struct{})
+	e.runsMu.Unlock()
+	for i := 0; i < n; i++ {
+		select {
+		case e.wake <- struct{}{}:
+		default:
+			return
+		}
+	}
+}
+
+func (e *Engine) heldIndexLocked(jobID string) int {
+	for i, qr := range e.held {
+		if qr.job.ID == jobID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (e *Engine) heldIndexByRunLocked(runID string) int {
+	for i, qr := range e.held {
+		if qr.runID == runID {
+			return i
+		}
+	}
+	return -1
+}
+
+// labelsFreeLocked reports whether every label limit that applies to job
+// has a free slot.
+func (e *Engine) labelsFreeLocked(job ReportJob) bool {
+	for _, key := range e.limitedLabels(job) {
+		if e.labelRuns[key] >= e.labelLimits[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// limitedLabels returns the "name=value" keys of Config.LabelLimits that
+// match the job's labels.
+func (e *Engine) limitedLabels(job ReportJob) []string {
+	if len(e.labelLimits) == 0 {
+		return nil
+	}
+	var keys []string
+	for name, value := range job.Labels {
+		key := name + "=" + value
+		if _, ok := e.labelLimits[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,224 @@
+package cronyx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCancelRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		policy  ConcurrencyPolicy
+		held    int // runs held when the second run is cancelled
+	}{
+		{name: "queued", workers: 1},
+		{name: "held", workers: 2, policy: ConcurrencyQueueOne, held: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, l := newTestEngine(t, Config{Workers: tt.workers})
+			job := testJob("daily", tt.policy)
+			running := mustEnqueue(t, e, job)
+			l.waitStarted(t)
+			waiting := mustEnqueue(t, e, job)
+			waitFor(t, "held runs", func() bool { return heldCount(e) == tt.held })
+
+			if err := e.CancelRun(waiting); err != nil {
+				t.Fatalf("CancelRun(waiting): %v", err)
+			}
+			if err := e.CancelRun(running); err != nil {
+				t.Fatalf("CancelRun(running): %v", err)
+			}
+
+			run := waitRun(t, e, running, RunCancelled)
+			if !strings.Contains(run.Error, ErrRunCancelled.Error()) {
+				t.Errorf("running run error = %q, want the cancel cause", run.Error)
+			}
+			run = waitRun(t, e, waiting, RunCancelled)
+			if run.Error != ErrRunCancelled.Error() || len(run.StageDurations) != 0 {
+				t.Errorf("waiting run = %+v, want cancelled before starting", run)
+			}
+			select {
+			case id := <-l.started:
+				t.Errorf("run %s started after being cancelled", id)
+			case <-time.After(20 * time.Millisecond):
+			}
+
+			if err := e.CancelRun(running); !errors.Is(err, ErrRunNotFound) {
+				t.Errorf("CancelRun of a finished run: err = %v, want ErrRunNotFound", err)
+			}
+		})
+	}
+}
+
+func TestCancelRunUnknown(t *testing.T) {
+	e, _ := newTestEngine(t, Config{})
+	if err := e.CancelRun("run-nope"); !errors.Is(err, ErrRunNotFound) {
+		t.Errorf("err = %v, want ErrRunNotFound", err)
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	e, l := newTestEngine(t, Config{Workers: 2})
+	job := testJob("daily", ConcurrencyQueueOne)
+	running := mustEnqueue(t, e, job)
+	l.waitStarted(t)
+	held := mustEnqueue(t, e, job)
+	waitFor(t, "held run", func() bool { return heldCount(e) == 1 })
+	other := mustEnqueue(t, e, testJob("weekly", ConcurrencyAllow))
+	l.waitStarted(t)
+
+	if n := e.CancelJob("daily"); n != 2 {
+		t.Errorf("CancelJob = %d, want 2", n)
+	}
+	waitRun(t, e, running, RunCancelled)
+	waitRun(t, e, held, RunCancelled)
+	if n := e.CancelJob("daily"); n != 0 {
+		t.Errorf("second CancelJob = %d, want 0", n)
+	}
+
+	// other jobs are left alone
+	l.releaseAll()
+	waitRun(t, e, other, RunSucceeded)
+}
+
+func TestTestExecuteRun(t *testing.T) {
+	e, l := newTestEngine(t, Config{})
+	l.releaseAll()
+
+	id, err := e.TestExecuteRun(context.Background(), testJob("daily", ConcurrencyAllow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.waitStarted(t) != id {
+		t.Error("loader saw a different run ID")
+	}
+	run := waitRun(t, e, id, RunSucceeded)
+	if run.Trigger != TriggerTest {
+		t.Errorf("Trigger = %s, want %s", run.Trigger, TriggerTest)
+	}
+	e.runsMu.Lock()
+	held, active := len(e.held), len(e.active)
+	e.runsMu.Unlock()
+	if held != 0 || active != 0 {
+		t.Errorf("run not released: %d held, %d active", held, active)
+	}
+}
+
+func TestTestExecuteRunCancel(t *testing.T) {
+	e, l := newTestEngine(t, Config{})
+
+	type result struct {
+		runID string
+		err   error
+	}
+	done := make(chan result)
+	go func() {
+		id, err := e.TestExecuteRun(context.Background(), testJob("daily", ConcurrencyAllow))
+		done <- result{id, err}
+	}()
+	id := l.waitStarted(t)
+	if got, err := e.GetRun(context.Background(), id); err != nil || got.Status != RunRunning {
+		t.Fatalf("run store has %+v, %v; want a running run", got, err)
+	}
+
+	if err := e.CancelRun(id); err != nil {
+		t.Fatalf("CancelRun: %v", err)
+	}
+	res := <-done
+	if res.runID != id || !errors.Is(res.err, context.Canceled) {
+		t.Errorf("TestExecuteRun = %s, %v; want %s, context.Canceled", res.runID, res.err, id)
+	}
+	waitRun(t, e, id, RunCancelled)
+}
+
+func TestTestExecuteRunConcurrency(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     ConcurrencyPolicy
+		timeout    time.Duration
+		wantErr    error
+		wantStatus RunStatus
+	}{
+		{name: "skip", policy: ConcurrencySkip, wantErr: ErrRunSkipped, wantStatus: RunSkipped},
+		{name: "held until ctx is done", policy: ConcurrencyQueueOne, timeout: 30 * time.Millisecond, wantErr: context.DeadlineExceeded, wantStatus: RunCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, l := newTestEngine(t, Config{})
+			job := testJob("daily", tt.policy)
+			mustEnqueue(t, e, job)
+			l.waitStarted(t)
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			id, err := e.TestExecuteRun(ctx, job)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			waitRun(t, e, id, tt.wantStatus)
+			if n := heldCount(e); n != 0 {
+				t.Errorf("%d runs still held", n)
+			}
+		})
+	}
+}
+
+func TestTestExecuteRunWaitsForSlot(t *testing.T) {
+	e, l := newTestEngine(t, Config{LabelLimits: map[string]int{"db=main": 1}})
+	job := testJob("daily", ConcurrencyAllow)
+	job.Labels = map[string]string{"db": "main"}
+	first := mustEnqueue(t, e, job)
+	l.waitStarted(t)
+
+	done := make(chan error)
+	go func() {
+		_, err := e.TestExecuteRun(context.Background(), job)
+		done <- err
+	}()
+	waitFor(t, "held run", func() bool { return heldCount(e) == 1 })
+	select {
+	case err := <-done:
+		t.Fatalf("TestExecuteRun returned %v while the label limit was taken", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	l.releaseAll()
+	if err := <-done; err != nil {
+		t.Fatalf("TestExecuteRun: %v", err)
+	}
+	waitRun(t, e, first, RunSucceeded)
+}
+
+func TestSkipCountsHeldRuns(t *testing.T) {
+	e, l := newTestEngine(t, Config{Workers: 2, LabelLimits: map[string]int{"db=main": 1}})
+	other := testJob("weekly", ConcurrencyAllow)
+	other.Labels = map[string]string{"db": "main"}
+	mustEnqueue(t, e, other)
+	l.waitStarted(t)
+
+	job := testJob("daily", ConcurrencySkip)
+	job.Labels = other.Labels
+	waiting := mustEnqueue(t, e, job)
+	waitFor(t, "held run", func() bool { return heldCount(e) == 1 })
+	skipped := mustEnqueue(t, e, job)
+
+	run := waitRun(t, e, skipped, RunSkipped)
+	if !strings.Contains(run.Error, "waiting to start") {
+		t.Errorf("skipped run error = %q", run.Error)
+	}
+	if _, err := e.TestExecuteRun(context.Background(), job); !errors.Is(err, ErrRunSkipped) {
+		t.Errorf("TestExecuteRun: err = %v, want ErrRunSkipped", err)
+	}
+
+	l.releaseAll()
+	waitRun(t, e, waiting, RunSucceeded)
+}
@@ -0,0 +1,73 @@
+package cronyx
+
+import (
+	"log"
+	"time"
+)
+
+const (
+	// DefaultQueueSize is the job queue buffer used when Config.QueueSize is zero.
+	DefaultQueueSize = 100
+	// DefaultJobTimeout applies to jobs without a Timeout when Config.DefaultTimeout is zero.
+	DefaultJobTimeout = 30 * time.Second
+)
+
+// CronMode selects how schedule expressions are parsed.
+type CronMode int
+
+const (
+	// CronWithSeconds expects six fields, seconds first ("0 30 9 * * *").
+	CronWithSeconds CronMode = iota
+	// CronStandard expects the classic five fields ("30 9 * * *").
+	CronStandard
+	// CronSecondsOptional accepts both five and six field expressions.
+	CronSecondsOptional
+)
+
+// Logger is the minimal logging interface used by the engine. *log.Logger
+// satisfies it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Config configures an Engine. Zero values fall back to the defaults used by
+// NewEngine.
+type Config struct {
+	Workers        int            // number of worker goroutines (default 1)
+	QueueSize      int            // job queue buffer size (default DefaultQueueSize)
+	DefaultTimeout time.Duration  // applied when ReportJob.Timeout is zero (default DefaultJobTimeout)
+	CronMode       CronMode       // schedule expression format (default CronWithSeconds)
+	Location       *time.Location // time zone schedules are evaluated in (default time.Local)
+	Logger         Logger         // engine logger (default log.Default())
+	RunStore       RunStore       // run history (default NewMemoryRunStore(DefaultRunHistory))
+	EnableMetrics  bool           // collect run counters and latency histograms
+	Overflow       OverflowPolicy // what to do with triggers when the queue is full (default OverflowBlock)
+	LabelLimits    map[string]int // max concurrent runs of jobs with a label, keyed "name=value"
+	// DeferValidation lets AddCronJob and UpdateJob accept jobs whose
+	// components aren't registered yet. Runs of such a job fail with
+	// ErrNoLoader, ErrNoRenderer, ErrNoOutput or ErrNoDelivery until they are.
+	DeferValidation bool
+}
+
+// withDefaults returns a copy of c with zero values filled in.
+func (c Config) withDefaults() Config {
+	if c.Workers <= 0 {
+		c.Workers = 1
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = DefaultQueueSize
+	}
+	if c.DefaultTimeout <= 0 {
+		c.DefaultTimeout = DefaultJobTimeout
+	}
+	if c.Location == nil {
+		c.Location = time.Local
+	}
+	if c.Logger == nil {
+		c.Logger = log.Default()
+	}
+	if c.RunStore == nil {
+		c.RunStore = NewMemoryRunStore(DefaultRunHistory)
+	}
+	return c
+}
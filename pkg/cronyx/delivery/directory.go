@@ -0,0 +1,401 @@
+package delivery
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx/outputs"
+)
+
+const (
+	// DefaultDirectoryPath is the run directory template used when none
+	// is configured.
+	DefaultDirectoryPath = `{{.JobID}}/{{.Date "20060102_150405"}}_{{.RunID}}`
+	// ManifestName is the manifest file written to every run directory.
+	ManifestName = "manifest.json"
+)
+
+// DirectoryDelivery copies the outputs of a run into a directory of their
+// own below a root folder, such as a shared drop folder, and describes
+// them in a manifest.
+//
+// Config keys:
+//
+//	dir           root folder (required unless Dir is set)
+//	path          run directory below dir, a file name template (see
+//	              outputs.NameData; default DefaultDirectoryPath)
+//	mode          "copy" (default) or "link" to hard-link files that are
+//	              on disk, falling back to a copy across file systems
+//	archive       "zip" or "tar.gz" bundles all files into one archive
+//	archive_name  archive name template (default outputs.DefaultFileName)
+//	keep          number of runs of the job to keep; older run
+//	              directories are removed after a successful delivery
+//
+// keep only looks below the job's directory: the leading part of path that
+// is the same for every run, e.g. dir/<job ID> for DefaultDirectoryPath. It
+// is rejected for paths without one and for runs without a job ID.
+//
+// Every file is written under a temporary name and renamed into place.
+// The manifest (ManifestName) is written last, so a run directory with a
+// manifest is complete.
+type DirectoryDelivery struct {
+	Dir string
+}
+
+// Manifest describes a run directory written by DirectoryDelivery.
+type Manifest struct {
+	Job       WebhookJob     `json:"job"`
+	Run       WebhookRun     `json:"run"`
+	CreatedAt time.Time      `json:"created_at"`
+	Archive   *ManifestFile  `json:"archive,omitempty"`
+	Files     []ManifestFile `json:"files"`
+}
+
+type ManifestFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+func (d DirectoryDelivery) Deliver(ctx context.Context, target cronyx.DeliveryConfig, files []cronyx.OutputFile) error {
+	root := valueOr(target["dir"], d.Dir)
+	if root == "" {
+		return cronyx.Permanent(fmt.Errorf("directory delivery: dir is required"))
+	}
+	mode := valueOr(target["mode"], "copy")
+	if mode != "copy" && mode != "link" {
+		return cronyx.Permanent(fmt.Errorf("directory delivery: unknown mode %q", mode))
+	}
+	archive := target["archive"]
+	if archive != "" && archive != "zip" && archive != "tar.gz" {
+		return cronyx.Permanent(fmt.Errorf("directory delivery: unknown archive format %q", archive))
+	}
+	keep := 0
+	if v := target["keep"]; v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return cronyx.Permanent(fmt.Errorf("directory delivery: invalid keep %q", v))
+		}
+		keep = n
+	}
+	info, _ := cronyx.RunInfoFromContext(ctx)
+	if keep > 0 && info.Job.ID == "" {
+		return cronyx.Permanent(fmt.Errorf("directory delivery: keep needs a run with a job ID"))
+	}
+
+	pathTpl := valueOr(target["path"], DefaultDirectoryPath)
+	nameData := outputs.NewNameData(ctx, "")
+	name, err := outputs.ExpandName(pathTpl, nameData)
+	if err != nil {
+		return cronyx.Permanent(fmt.Errorf("directory delivery: invalid path: %w", err))
+	}
+	var jobDir string
+	if keep > 0 {
+		if jobDir = commonDir(pathTpl, nameData); jobDir == "" {
+			return cronyx.Permanent(fmt.Errorf("directory delivery: keep needs a path whose runs share a job directory, e.g. %q", DefaultDirectoryPath))
+		}
+	}
+	runDir := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		return fmt.Errorf("directory delivery: failed to create run directory: %w", err)
+	}
+
+	m := Manifest{
+		Job:       WebhookJob{ID: info.Job.ID, Name: info.Job.Name, Labels: info.Job.Labels},
+		Run:       WebhookRun{ID: info.RunID, Trigger: string(info.Trigger), StartedAt: info.StartedAt},
+		CreatedAt: time.Now().UTC(),
+		Files:     []ManifestFile{},
+	}
+
+	if archive != "" {
+		archiveName, err := outputs.ExpandName(valueOr(target["archive_name"], outputs.DefaultFileName), outputs.NewNameData(ctx, archive))
+		if err != nil {
+			return cronyx.Permanent(fmt.Errorf("directory delivery: invalid archive_name: %w", err))
+		}
+		entry, err := writeArchive(filepath.Join(runDir, filepath.Base(archiveName)), archive, files, info.StartedAt, &m)
+		if err != nil {
+			return fmt.Errorf("directory delivery: failed to write archive: %w", err)
+		}
+		m.Archive = &entry
+	} else {
+		for _, f := range files {
+			entry, err := placeFile(runDir, f, mode == "link")
+			if err != nil {
+				return fmt.Errorf("directory delivery: %s: %w", f.Name, err)
+			}
+			m.Files = append(m.Files, entry)
+		}
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("directory delivery: failed to encode manifest: %w", err)
+	}
+	err = writeFileAtomic(filepath.Join(runDir, ManifestName), func(w io.Writer) error {
+		_, err := w.Write(append(data, '\n'))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("directory delivery: failed to write manifest: %w", err)
+	}
+
+	if keep > 0 {
+		if err := pruneRuns(filepath.Join(root, filepath.FromSlash(jobDir)), info.Job.ID, keep); err != nil {
+			return fmt.Errorf("directory delivery: failed to prune old runs: %w", err)
+		}
+	}
+	return nil
+}
+
+// placeFile hard-links (when link is set) or copies f into dir.
+func placeFile(dir string, f cronyx.OutputFile, link bool) (ManifestFile, error) {
+	dst := filepath.Join(dir, fileName(f))
+	entry := ManifestFile{Name: fileName(f)}
+
+	if link && f.Path != "" {
+		tmp := filepath.Join(dir, "."+entry.Name+".link.tmp")
+		os.Remove(tmp)
+		if err := os.Link(f.Path, tmp); err == nil {
+			if err := os.Rename(tmp, dst); err != nil {
+				os.Remove(tmp)
+				return entry, err
+			}
+			src, err := os.Open(dst)
+			if err != nil {
+				return entry, err
+			}
+			defer src.Close()
+			entry.Size, entry.SHA256, err = hashCopy(io.Discard, src)
+			return entry, err
+		}
+	}
+
+	err := writeFileAtomic(dst, func(w io.Writer) error {
+		src, err := openFile(f)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		entry.Size, entry.SHA256, err = hashCopy(w, src)
+		return err
+	})
+	return entry, err
+}
+
+// writeArchive bundles files into a zip or tar.gz archive at path, listing
+// them in m.
+func writeArchive(path, format string, files []cronyx.OutputFile, modTime time.Time, m *Manifest) (ManifestFile, error) {
+	if modTime.IsZero() {
+		modTime = time.Now()
+	}
+	entry := ManifestFile{Name: filepath.Base(path)}
+	err := writeFileAtomic(path, func(w io.Writer) error {
+		h := sha256.New()
+		counter := &countingWriter{w: io.MultiWriter(w, h)}
+		var err error
+		if format == "zip" {
+			err = writeZip(counter, files, modTime, m)
+		} else {
+			err = writeTarGz(counter, files, modTime, m)
+		}
+		entry.Size, entry.SHA256 = counter.n, hex.EncodeToString(h.Sum(nil))
+		return err
+	})
+	return entry, err
+}
+
+func writeZip(w io.Writer, files []cronyx.OutputFile, modTime time.Time, m *Manifest) error {
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: fileName(f), Method: zip.Deflate, Modified: modTime})
+		if err != nil {
+			return err
+		}
+		if err := addEntry(fw, f, m); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeTarGz(w io.Writer, files []cronyx.OutputFile, modTime time.Time, m *Manifest) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		// tar needs the size up front
+		size := int64(len(f.Data))
+		if f.Data == nil && f.Path != "" {
+			st, err := os.Stat(f.Path)
+			if err != nil {
+				return fmt.Errorf("%s: %w", f.Name, err)
+			}
+			size = st.Size()
+		}
+		hdr := &tar.Header{Name: fileName(f), Mode: 0644, Size: size, ModTime: modTime, Format: tar.FormatPAX}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if err := addEntry(tw, f, m); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func addEntry(w io.Writer, f cronyx.OutputFile, m *Manifest) error {
+	src, err := openFile(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", f.Name, err)
+	}
+	defer src.Close()
+	size, sum, err := hashCopy(w, src)
+	if err != nil {
+		return fmt.Errorf("%s: %w", f.Name, err)
+	}
+	m.Files = append(m.Files, ManifestFile{Name: fileName(f), Size: size, SHA256: sum})
+	return nil
+}
+
+// commonDir returns the leading directories of the path template that
+// don't change with the run ID or time, or "" when there are none.
+func commonDir(tpl string, data outputs.NameData) string {
+	other := data
+	other.RunID += "-other"
+	other.Time = data.Time.AddDate(1, 1, 1).Add(time.Hour + time.Minute + time.Second + time.Millisecond)
+	a, err := outputs.ExpandName(tpl, data)
+	if err != nil {
+		return ""
+	}
+	b, err := outputs.ExpandName(tpl, other)
+	if err != nil {
+		return ""
+	}
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	n := 0
+	for n < len(as)-1 && n < len(bs)-1 && as[n] == bs[n] {
+		n++
+	}
+	return strings.Join(as[:n], "/")
+}
+
+// pruneRuns removes all but the newest keep run directories of jobID
+// below root, the job's directory. Run directories are found by their
+// manifests; directories left empty are removed as well.
+func pruneRuns(root, jobID string, keep int) error {
+	if jobID == "" {
+		return fmt.Errorf("no job ID")
+	}
+	root = filepath.Clean(root)
+	type run struct {
+		dir  string
+		time time.Time
+	}
+	var runs []run
+	err := filepath.WalkDir(root, func(path string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if e.IsDir() || e.Name() != ManifestName || filepath.Dir(path) == root {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var m Manifest
+		if json.Unmarshal(data, &m) != nil || m.Job.ID != jobID {
+			return nil
+		}
+		t := m.Run.StartedAt
+		if t.IsZero() {
+			t = m.CreatedAt
+		}
+		runs = append(runs, run{dir: filepath.Dir(path), time: t})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(runs) <= keep {
+		return nil
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].time.After(runs[j].time) })
+	var errs []error
+	for _, r := range runs[keep:] {
+		if err := os.RemoveAll(r.dir); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// drop parents the removal left empty, e.g. date folders
+		for dir := filepath.Dir(r.dir); dir != root && strings.HasPrefix(dir, root+string(filepath.Separator)); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// hashCopy copies src to w and returns the size and hex SHA-256 of the data.
+func hashCopy(w io.Writer, src io.Reader) (int64, string, error) {
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), src)
+	return n, hex.EncodeToString(h.Sum(nil)), err
+}
+
+// writeFileAtomic writes a file through write into a temporary file next
+// to path and renames it into place.
+func writeFileAtomic(path string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,143 @@
+package delivery
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+// deliverRun delivers one file for a run of jobID started at day d of
+// March 2025.
+func deliverRun(t *testing.T, cfg cronyx.DeliveryConfig, jobID, runID string, d int) error {
+	t.Helper()
+	ctx := context.Background()
+	if jobID != "" {
+		ctx = cronyx.ContextWithRunInfo(ctx, cronyx.RunInfo{
+			RunID:     runID,
+			Job:       cronyx.ReportJob{ID: jobID},
+			StartedAt: time.Date(2025, 3, d, 8, 0, 0, 0, time.UTC),
+		})
+	}
+	return DirectoryDelivery{}.Deliver(ctx, cfg, []cronyx.OutputFile{{Name: "report.csv", Data: []byte("a,b\n")}})
+}
+
+// manifests returns the run directories below root, relative and sorted.
+func manifests(t *testing.T, root string) []string {
+	t.Helper()
+	var dirs []string
+	filepath.WalkDir(root, func(path string, e os.DirEntry, err error) error {
+		if err == nil && e.Name() == ManifestName {
+			rel, _ := filepath.Rel(root, filepath.Dir(path))
+			dirs = append(dirs, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(dirs)
+	return dirs
+}
+
+func TestDirectoryDeliveryManifest(t *testing.T) {
+	root := t.TempDir()
+	if err := deliverRun(t, cronyx.DeliveryConfig{"dir": root, "path": "{{.JobID}}/{{.RunID}}"}, "daily", "run-1", 1); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "daily", "run-1", ManifestName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("a,b\n"))
+	if m.Job.ID != "daily" || m.Run.ID != "run-1" || len(m.Files) != 1 || m.Files[0].Size != 4 ||
+		m.Files[0].SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("manifest = %+v", m)
+	}
+}
+
+func TestDirectoryDeliveryKeep(t *testing.T) {
+	root := t.TempDir()
+	cfg := cronyx.DeliveryConfig{"dir": root, "path": "{{.JobID}}/{{.RunID}}", "keep": "2"}
+
+	// another job's runs and a stray copy of a daily run outside its job
+	// directory are left alone
+	if err := deliverRun(t, cronyx.DeliveryConfig{"dir": root, "path": "{{.JobID}}/{{.RunID}}"}, "weekly", "w1", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliverRun(t, cronyx.DeliveryConfig{"dir": filepath.Join(root, "archive"), "path": "copies/{{.RunID}}"}, "daily", "old", 1); err != nil {
+		t.Fatal(err)
+	}
+	for i, run := range []string{"d1", "d2", "d3", "d4"} {
+		// delivered out of order: pruning goes by the run's start time
+		day := []int{2, 5, 3, 4}[i]
+		if err := deliverRun(t, cfg, "daily", run, day); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"archive/copies/old", "daily/d2", "daily/d4", "weekly/w1"}
+	if got := manifests(t, root); !reflect.DeepEqual(got, want) {
+		t.Errorf("run directories = %q, want %q", got, want)
+	}
+}
+
+func TestDirectoryDeliveryKeepDateFolders(t *testing.T) {
+	root := t.TempDir()
+	cfg := cronyx.DeliveryConfig{"dir": root, "path": `reports/{{.JobID}}/{{.Date "2006/01/02"}}/{{.RunID}}`, "keep": "1"}
+	for day, run := range map[int]string{1: "r1", 2: "r2", 3: "r3"} {
+		if err := deliverRun(t, cfg, "daily", run, day); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := manifests(t, root); !reflect.DeepEqual(got, []string{"reports/daily/2025/03/03/r3"}) {
+		t.Errorf("run directories = %q", got)
+	}
+	// the emptied date folders are gone, the job directory stays
+	entries, _ := os.ReadDir(filepath.Join(root, "reports", "daily", "2025", "03"))
+	if len(entries) != 1 {
+		t.Errorf("date folders left: %d", len(entries))
+	}
+}
+
+func TestDirectoryDeliveryKeepRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		jobID   string
+		wantErr string
+	}{
+		{"no job ID", "", "", "keep needs a run with a job ID"},
+		{"no job directory", "{{.RunID}}", "daily", "share a job directory"},
+		{"job in the run name", `{{.Date "20060102"}}_{{.JobID}}`, "daily", "share a job directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			cfg := cronyx.DeliveryConfig{"dir": root, "keep": "1"}
+			if tt.path != "" {
+				cfg["path"] = tt.path
+			}
+			err := deliverRun(t, cfg, tt.jobID, "run-1", 1)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			if cronyx.DefaultRetryable(err) {
+				t.Error("error is retryable, want permanent")
+			}
+			if got := manifests(t, root); len(got) != 0 {
+				t.Errorf("wrote %q before rejecting keep", got)
+			}
+		})
+	}
+}
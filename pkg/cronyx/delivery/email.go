@@ -0,0 +1,466 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx/outputs"
+)
+
+// DefaultEmailSubject is used when the delivery config has no subject.
+const DefaultEmailSubject = `{{.JobName}} report {{.Date "2006-01-02"}}`
+
+// EmailDelivery sends the report over SMTP. The message carries the
+// rendered report as text and HTML alternatives and attaches the
+// generated files.
+//
+// Config keys:
+//
+//	to, cc, bcc      comma-separated recipient lists (at least one required)
+//	from             sender address (default: username, or EmailDelivery.From)
+//	subject          text/template with outputs.NameData fields (default DefaultEmailSubject)
+//	smtp_host        server host (required unless EmailDelivery.Host is set)
+//	smtp_port        server port (default 465 with implicit TLS, else 587)
+//	username         login user; no authentication when empty
+//	password         login password
+//	tls              "auto" (default), "starttls", "implicit" or "none"
+//	auth             "plain" or "login" (default: what the server offers)
+//	attach           "false" sends the message without attachments
+//
+// With tls "auto", port 465 uses implicit TLS and other ports upgrade
+// with STARTTLS when the server offers it. Rejections with a 5xx reply
+// and configuration errors are marked cronyx.Permanent.
+type EmailDelivery struct {
+	// Defaults for the matching config keys.
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	// TLSConfig is used for STARTTLS and implicit TLS. ServerName defaults
+	// to the SMTP host.
+	TLSConfig *tls.Config
+	// Timeout bounds connecting when the context has no deadline (default 30s).
+	Timeout time.Duration
+}
+
+type emailConfig struct {
+	host, port, username, password string
+	tlsMode, auth                  string
+	from                           *mail.Address
+	to, cc, bcc                    []*mail.Address
+	subject                        string
+	attach                         bool
+}
+
+func (d EmailDelivery) Deliver(ctx context.Context, target cronyx.DeliveryConfig, files []cronyx.OutputFile) error {
+	cfg, err := d.config(ctx, target)
+	if err != nil {
+		return cronyx.Permanent(fmt.Errorf("email delivery: %w", err))
+	}
+	msg, err := buildMessage(ctx, cfg, files)
+	if err != nil {
+		return fmt.Errorf("email delivery: failed to build message: %w", err)
+	}
+	if err := d.send(ctx, cfg, msg); err != nil {
+		var tpErr *textproto.Error
+		if errors.As(err, &tpErr) && tpErr.Code >= 500 {
+			err = cronyx.Permanent(err)
+		}
+		return fmt.Errorf("email delivery: %w", err)
+	}
+	return nil
+}
+
+func (d EmailDelivery) config(ctx context.Context, target cronyx.DeliveryConfig) (emailConfig, error) {
+	cfg := emailConfig{
+		host:     valueOr(target["smtp_host"], d.Host),
+		port:     valueOr(target["smtp_port"], d.Port),
+		username: valueOr(target["username"], d.Username),
+		password: valueOr(target["password"], d.Password),
+		tlsMode:  strings.ToLower(valueOr(target["tls"], "auto")),
+		auth:     strings.ToLower(target["auth"]),
+		attach:   target["attach"] != "false",
+	}
+	if cfg.host == "" {
+		return cfg, fmt.Errorf("smtp_host is required")
+	}
+	switch cfg.tlsMode {
+	case "auto", "starttls", "implicit", "none":
+	default:
+		return cfg, fmt.Errorf("invalid tls mode %q", cfg.tlsMode)
+	}
+	switch cfg.auth {
+	case "", "plain", "login":
+	default:
+		return cfg, fmt.Errorf("unsupported auth mechanism %q", cfg.auth)
+	}
+	if cfg.port == "" {
+		cfg.port = "587"
+		if cfg.tlsMode == "implicit" {
+			cfg.port = "465"
+		}
+	}
+	if _, err := strconv.Atoi(cfg.port); err != nil {
+		return cfg, fmt.Errorf("invalid smtp_port %q", cfg.port)
+	}
+	if cfg.tlsMode == "auto" && cfg.port == "465" {
+		cfg.tlsMode = "implicit"
+	}
+
+	from := valueOr(target["from"], d.From)
+	if from == "" && strings.Contains(cfg.username, "@") {
+		from = cfg.username
+	}
+	if from == "" {
+		return cfg, fmt.Errorf("from is required")
+	}
+	var err error
+	if cfg.from, err = mail.ParseAddress(from); err != nil {
+		return cfg, fmt.Errorf("invalid from address %q: %w", from, err)
+	}
+	for _, list := range []struct {
+		key  string
+		dest *[]*mail.Address
+	}{{"to", &cfg.to}, {"cc", &cfg.cc}, {"bcc", &cfg.bcc}} {
+		if strings.TrimSpace(target[list.key]) == "" {
+			continue
+		}
+		if *list.dest, err = mail.ParseAddressList(target[list.key]); err != nil {
+			return cfg, fmt.Errorf("invalid %s list: %w", list.key, err)
+		}
+	}
+	if len(cfg.to)+len(cfg.cc)+len(cfg.bcc) == 0 {
+		return cfg, fmt.Errorf("at least one of to, cc or bcc is required")
+	}
+
+	tmpl, err := template.New("subject").Option("missingkey=zero").Parse(valueOr(target["subject"], DefaultEmailSubject))
+	if err != nil {
+		return cfg, fmt.Errorf("invalid subject template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, outputs.NewNameData(ctx, "")); err != nil {
+		return cfg, fmt.Errorf("failed to expand subject: %w", err)
+	}
+	cfg.subject = strings.Join(strings.Fields(buf.String()), " ")
+	return cfg, nil
+}
+
+// buildMessage assembles the MIME message: a multipart/alternative body
+// with the report as text and HTML, followed by the attachments.
+func buildMessage(ctx context.Context, cfg emailConfig, files []cronyx.OutputFile) ([]byte, error) {
+	var buf bytes.Buffer
+	h := func(key, value string) { fmt.Fprintf(&buf, "%s: %s\r\n", key, value) }
+
+	h("From", cfg.from.String())
+	if len(cfg.to) > 0 {
+		h("To", joinAddresses(cfg.to))
+	}
+	if len(cfg.cc) > 0 {
+		h("Cc", joinAddresses(cfg.cc))
+	}
+	h("Subject", mime.QEncoding.Encode("utf-8", cfg.subject))
+	h("Date", time.Now().Format(time.RFC1123Z))
+	h("Message-ID", messageID(cfg.from.Address))
+	h("MIME-Version", "1.0")
+
+	text, htmlBody := messageBodies(ctx, files)
+
+	mixed := multipart.NewWriter(&buf)
+	h("Content-Type", `multipart/mixed; boundary="`+mixed.Boundary()+`"`)
+	buf.WriteString("\r\n")
+
+	var altBuf bytes.Buffer
+	alt := multipart.NewWriter(&altBuf)
+	for _, body := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", htmlBody},
+	} {
+		w, err := alt.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {body.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(body.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := alt.Close(); err != nil {
+		return nil, err
+	}
+	altPart, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {`multipart/alternative; boundary="` + alt.Boundary() + `"`},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := altPart.Write(altBuf.Bytes()); err != nil {
+		return nil, err
+	}
+
+	if cfg.attach {
+		for _, f := range files {
+			if err := attachFile(mixed, f); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// messageBodies returns the text and HTML bodies: the rendered report
+// when the engine provides it, otherwise a list of the attached files.
+func messageBodies(ctx context.Context, files []cronyx.OutputFile) (string, string) {
+	if info, ok := cronyx.RunInfoFromContext(ctx); ok && info.Report != nil {
+		return outputs.PlainText(*info.Report), outputs.HTMLDocument(ctx, *info.Report, "")
+	}
+
+	var text strings.Builder
+	text.WriteString("The report is attached:\n\n")
+	for _, f := range files {
+		fmt.Fprintf(&text, "- %s\n", f.Name)
+	}
+	doc := cronyx.RenderedDoc{HTML: "<pre>" + html.EscapeString(text.String()) + "</pre>"}
+	return text.String(), outputs.HTMLDocument(ctx, doc, "")
+}
+
+func attachFile(w *multipart.Writer, f cronyx.OutputFile) error {
+	data, err := fileData(f)
+	if err != nil {
+		return fmt.Errorf("failed to read attachment: %w", err)
+	}
+	name := fileName(f)
+	contentType := contentType(name)
+
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": name})},
+	})
+	if err != nil {
+		return err
+	}
+
+	// base64 in lines of 76 characters, as RFC 2045 requires
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := fmt.Fprintf(part, "%s\r\n", encoded[:76]); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = fmt.Fprintf(part, "%s\r\n", encoded)
+	return err
+}
+
+func joinAddresses(addrs []*mail.Address) string {
+	s := make([]string, len(addrs))
+	for i, a := range addrs {
+		s[i] = a.String()
+	}
+	return strings.Join(s, ", ")
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domain = from[i+1:]
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return fmt.Sprintf("<%x.%d@%s>", b, time.Now().UnixNano(), domain)
+}
+
+// send delivers msg over one SMTP session.
+func (d EmailDelivery) send(ctx context.Context, cfg emailConfig, msg []byte) error {
+	addr := net.JoinHostPort(cfg.host, cfg.port)
+	tlsConfig := &tls.Config{}
+	if d.TLSConfig != nil {
+		tlsConfig = d.TLSConfig.Clone()
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = cfg.host
+	}
+
+	timeout := d.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if cfg.tlsMode == "implicit" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	// Bound the whole session by the context.
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, cfg.host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := c.Hello(localName()); err != nil {
+		return err
+	}
+	if cfg.tlsMode == "starttls" || cfg.tlsMode == "auto" {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("starttls failed: %w", err)
+			}
+		} else if cfg.tlsMode == "starttls" {
+			return cronyx.Permanent(fmt.Errorf("server %s does not support STARTTLS", addr))
+		}
+	}
+
+	if cfg.username != "" {
+		auth, err := smtpAuth(c, cfg)
+		if err != nil {
+			return err
+		}
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+
+	if err := c.Mail(cfg.from.Address); err != nil {
+		return err
+	}
+	for _, list := range [][]*mail.Address{cfg.to, cfg.cc, cfg.bcc} {
+		for _, rcpt := range list {
+			if err := c.Rcpt(rcpt.Address); err != nil {
+				return fmt.Errorf("recipient %s rejected: %w", rcpt.Address, err)
+			}
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	// The message is accepted once DATA completes; a failed QUIT doesn't
+	// undo that, so it must not trigger a retry.
+	c.Quit()
+	return nil
+}
+
+// smtpAuth picks the configured mechanism, or the first of PLAIN and
+// LOGIN the server advertises.
+func smtpAuth(c *smtp.Client, cfg emailConfig) (smtp.Auth, error) {
+	mech := cfg.auth
+	if mech == "" {
+		ok, offered := c.Extension("AUTH")
+		if !ok {
+			return nil, cronyx.Permanent(fmt.Errorf("server does not support authentication"))
+		}
+		mechs := strings.Fields(strings.ToUpper(offered))
+		switch {
+		case slices.Contains(mechs, "PLAIN"):
+			mech = "plain"
+		case slices.Contains(mechs, "LOGIN"):
+			mech = "login"
+		default:
+			return nil, cronyx.Permanent(fmt.Errorf("no supported auth mechanism in %q", offered))
+		}
+	}
+	if mech == "login" {
+		return &loginAuth{username: cfg.username, password: cfg.password, host: cfg.host}, nil
+	}
+	return smtp.PlainAuth("", cfg.username, cfg.password, cfg.host), nil
+}
+
+// loginAuth implements the LOGIN mechanism, which net/smtp lacks. Like
+// smtp.PlainAuth it refuses to send credentials in the clear except to
+// localhost.
+type loginAuth struct {
+	username, password, host string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	prompt := strings.ToLower(strings.TrimSpace(string(fromServer)))
+	switch {
+	case strings.HasPrefix(prompt, "user"):
+		return []byte(a.username), nil
+	case strings.HasPrefix(prompt, "pass"):
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected LOGIN challenge %q", fromServer)
+}
+
+func isLocalhost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+func localName() string {
+	if name, err := os.Hostname(); err == nil && name != "" {
+		return name
+	}
+	return "localhost"
+}
+
+func valueOr(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
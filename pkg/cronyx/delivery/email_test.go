@@ -0,0 +1,325 @@
+package delivery
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+// fakeSMTP is a minimal SMTP server that records one session per
+// connection. It offers AUTH PLAIN and LOGIN but no STARTTLS.
+type fakeSMTP struct {
+	ln       net.Listener
+	rejectTo string // RCPT address answered with 550
+
+	mu       sync.Mutex
+	sessions []smtpSession
+}
+
+type smtpSession struct {
+	auth string // "PLAIN user pass" or "LOGIN user pass"
+	from string
+	rcpt []string
+	data string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTP{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTP) port() string {
+	_, port, _ := net.SplitHostPort(s.ln.Addr().String())
+	return port
+}
+
+func (s *fakeSMTP) last(t *testing.T) smtpSession {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.sessions) == 0 {
+		t.Fatal("no SMTP session recorded")
+	}
+	return s.sessions[len(s.sessions)-1]
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	readLine := func() (string, bool) {
+		line, err := r.ReadString('\n')
+		return strings.TrimRight(line, "\r\n"), err == nil
+	}
+	decode := func(s string) string {
+		b, _ := base64.StdEncoding.DecodeString(s)
+		return string(b)
+	}
+
+	var sess smtpSession
+	reply("220 fake ESMTP")
+	for {
+		line, ok := readLine()
+		if !ok {
+			return
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "EHLO", "HELO":
+			reply("250-fake")
+			reply("250 AUTH PLAIN LOGIN")
+		case "AUTH":
+			mech, initial, _ := strings.Cut(arg, " ")
+			switch strings.ToUpper(mech) {
+			case "PLAIN":
+				parts := strings.Split(decode(initial), "\x00")
+				sess.auth = "PLAIN " + strings.Join(parts[1:], " ")
+			case "LOGIN":
+				reply("334 " + base64.StdEncoding.EncodeToString([]byte("Username:")))
+				user, _ := readLine()
+				reply("334 " + base64.StdEncoding.EncodeToString([]byte("Password:")))
+				pass, _ := readLine()
+				sess.auth = "LOGIN " + decode(user) + " " + decode(pass)
+			}
+			reply("235 ok")
+		case "MAIL":
+			sess.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			reply("250 ok")
+		case "RCPT":
+			addr := strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+			if addr == s.rejectTo {
+				reply("550 no such user")
+				continue
+			}
+			sess.rcpt = append(sess.rcpt, addr)
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, ok := readLine()
+				if !ok || l == "." {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, ".") + "\r\n")
+			}
+			sess.data = data.String()
+			s.mu.Lock()
+			s.sessions = append(s.sessions, sess)
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestEmailDelivery(t *testing.T) {
+	srv := newFakeSMTP(t)
+	files := []cronyx.OutputFile{
+		{Name: "report.csv", Data: []byte("a,b\n1,2\n")},
+		{Name: "report.html", Data: []byte("<p>hi</p>")},
+	}
+	info := cronyx.RunInfo{
+		RunID:     "run-1",
+		Job:       cronyx.ReportJob{ID: "daily", Name: "Daily Sales"},
+		StartedAt: time.Date(2025, 3, 4, 8, 0, 0, 0, time.UTC),
+		Report:    &cronyx.RenderedDoc{Content: "# Sales\n\nTotal: **42**", HTML: "<h1>Sales</h1><p>Total: <strong>42</strong></p>", Meta: map[string]interface{}{"format": "markdown"}},
+	}
+	ctx := cronyx.ContextWithRunInfo(context.Background(), info)
+
+	tests := []struct {
+		name        string
+		cfg         cronyx.DeliveryConfig
+		wantAuth    string
+		wantRcpt    []string
+		wantSubject string
+		wantFiles   []string
+	}{
+		{
+			name:        "plain auth with default subject",
+			cfg:         cronyx.DeliveryConfig{"to": "a@example.com", "username": "bot@example.com", "password": "pw"},
+			wantAuth:    "PLAIN bot@example.com pw",
+			wantRcpt:    []string{"a@example.com"},
+			wantSubject: "Daily Sales report 2025-03-04",
+			wantFiles:   []string{"report.csv", "report.html"},
+		},
+		{
+			name: "login auth with every recipient list",
+			cfg: cronyx.DeliveryConfig{
+				"to": "A <a@example.com>, b@example.com", "cc": "c@example.com", "bcc": "d@example.com",
+				"from": "Reports <reports@example.com>", "username": "u", "password": "p", "auth": "login",
+				"subject": "{{.JobID}} / {{.RunID}}",
+			},
+			wantAuth:    "LOGIN u p",
+			wantRcpt:    []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com"},
+			wantSubject: "daily / run-1",
+			wantFiles:   []string{"report.csv", "report.html"},
+		},
+		{
+			name:        "no auth, no attachments",
+			cfg:         cronyx.DeliveryConfig{"to": "a@example.com", "from": "r@example.com", "attach": "false", "tls": "none"},
+			wantRcpt:    []string{"a@example.com"},
+			wantSubject: "Daily Sales report 2025-03-04",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := cronyx.DeliveryConfig{"smtp_host": "127.0.0.1", "smtp_port": srv.port()}
+			for k, v := range tt.cfg {
+				cfg[k] = v
+			}
+			if err := (EmailDelivery{}).Deliver(ctx, cfg, files); err != nil {
+				t.Fatal(err)
+			}
+
+			sess := srv.last(t)
+			if sess.auth != tt.wantAuth {
+				t.Errorf("auth = %q, want %q", sess.auth, tt.wantAuth)
+			}
+			if !reflect.DeepEqual(sess.rcpt, tt.wantRcpt) {
+				t.Errorf("rcpt = %v, want %v", sess.rcpt, tt.wantRcpt)
+			}
+
+			msg, err := mail.ReadMessage(strings.NewReader(sess.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+			if subject != tt.wantSubject {
+				t.Errorf("Subject = %q, want %q", subject, tt.wantSubject)
+			}
+			if msg.Header.Get("Bcc") != "" {
+				t.Error("message has a Bcc header")
+			}
+
+			bodies, attachments := readMIME(t, msg)
+			if !strings.Contains(bodies["text/plain"], "Total: 42") {
+				t.Errorf("text body = %q", bodies["text/plain"])
+			}
+			if !strings.Contains(bodies["text/html"], "<strong>42</strong>") {
+				t.Errorf("html body = %q", bodies["text/html"])
+			}
+			var names []string
+			for name, data := range attachments {
+				names = append(names, name)
+				for _, f := range files {
+					if f.Name == name && data != string(f.Data) {
+						t.Errorf("attachment %s = %q, want %q", name, data, f.Data)
+					}
+				}
+			}
+			if len(names) != len(tt.wantFiles) {
+				t.Errorf("attachments = %v, want %v", names, tt.wantFiles)
+			}
+		})
+	}
+}
+
+// readMIME returns the alternative bodies by content type and the
+// attachments by file name.
+func readMIME(t *testing.T, msg *mail.Message) (map[string]string, map[string]string) {
+	t.Helper()
+	bodies, attachments := map[string]string{}, map[string]string{}
+	var walk func(r io.Reader, contentType string)
+	walk = func(r io.Reader, contentType string) {
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mr := multipart.NewReader(r, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", mediaType, err)
+			}
+			ct := part.Header.Get("Content-Type")
+			if strings.HasPrefix(ct, "multipart/") {
+				walk(part, ct)
+				continue
+			}
+			data, _ := io.ReadAll(part)
+			if name := part.FileName(); name != "" {
+				decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(data), "\r\n", ""))
+				if err != nil {
+					t.Fatalf("attachment %s: %v", name, err)
+				}
+				attachments[name] = string(decoded)
+				continue
+			}
+			// multipart.Reader decodes quoted-printable parts
+			mt, _, _ := mime.ParseMediaType(ct)
+			bodies[mt] = string(data)
+		}
+	}
+	walk(msg.Body, msg.Header.Get("Content-Type"))
+	return bodies, attachments
+}
+
+func TestEmailDeliveryErrors(t *testing.T) {
+	srv := newFakeSMTP(t)
+	srv.rejectTo = "nobody@example.com"
+
+	tests := []struct {
+		name      string
+		cfg       cronyx.DeliveryConfig
+		wantErr   string
+		permanent bool
+	}{
+		{"no recipients", cronyx.DeliveryConfig{"from": "r@example.com"}, "at least one of to, cc or bcc", true},
+		{"no from", cronyx.DeliveryConfig{"to": "a@example.com"}, "from is required", true},
+		{"bad tls mode", cronyx.DeliveryConfig{"to": "a@example.com", "from": "r@example.com", "tls": "maybe"}, "invalid tls mode", true},
+		{"bad subject", cronyx.DeliveryConfig{"to": "a@example.com", "from": "r@example.com", "subject": "{{.Nope"}, "invalid subject template", true},
+		{"starttls required", cronyx.DeliveryConfig{"to": "a@example.com", "from": "r@example.com", "tls": "starttls"}, "does not support STARTTLS", true},
+		{"recipient rejected", cronyx.DeliveryConfig{"to": "nobody@example.com", "from": "r@example.com"}, "recipient nobody@example.com rejected", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := cronyx.DeliveryConfig{"smtp_host": "127.0.0.1", "smtp_port": srv.port()}
+			for k, v := range tt.cfg {
+				cfg[k] = v
+			}
+			err := EmailDelivery{}.Deliver(context.Background(), cfg, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			if got := !cronyx.DefaultRetryable(err); got != tt.permanent {
+				t.Errorf("permanent = %v, want %v", got, tt.permanent)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package delivery
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+// fileData returns the contents of an output file, reading it from disk
+// when the generator didn't keep the bytes.
+func fileData(f cronyx.OutputFile) ([]byte, error) {
+	if f.Data != nil || f.Path == "" {
+		return f.Data, nil
+	}
+	return os.ReadFile(f.Path)
+}
+
+// openFile opens an output file for reading, from memory or disk.
+func openFile(f cronyx.OutputFile) (io.ReadCloser, error) {
+	if f.Data != nil || f.Path == "" {
+		return io.NopCloser(bytes.NewReader(f.Data)), nil
+	}
+	return os.Open(f.Path)
+}
+
+func fileName(f cronyx.OutputFile) string {
+	return valueOr(f.Name, filepath.Base(f.Path))
+}
+
+// contentType guesses a MIME type from the file extension.
+func contentType(name string) string {
+	if ct := mime.TypeByExtension(filepath.Ext(name)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
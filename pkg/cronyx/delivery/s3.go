@@ -0,0 +1,429 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx/outputs"
+)
+
+const (
+	// DefaultS3PartSize is the multipart upload part size (S3's minimum
+	// for all parts but the last is 5 MiB).
+	DefaultS3PartSize = 8 << 20
+	// DefaultS3MultipartThreshold is the file size from which uploads
+	// switch to multipart.
+	DefaultS3MultipartThreshold = 16 << 20
+
+	minS3PartSize = 5 << 20
+	maxS3Parts    = 10000
+)
+
+// S3Delivery uploads every output file to an S3-compatible object store,
+// signing requests with AWS Signature Version 4. Objects are named
+// <prefix>/<file name> and carry the job and run IDs as metadata
+// (x-amz-meta-job-id, x-amz-meta-run-id).
+//
+// Config keys (each falls back to the matching field):
+//
+//	bucket         bucket name (required)
+//	prefix         key prefix; a file name template such as
+//	               "reports/{{.JobID}}/{{.Date \"2006/01/02\"}}" (see outputs.NameData)
+//	endpoint       service URL, e.g. "http://localhost:9000" for MinIO
+//	               (default https://s3.<region>.amazonaws.com)
+//	region         signing region (default us-east-1)
+//	path_style     "true" for <endpoint>/<bucket>/<key> URLs; the default
+//	               when endpoint is set, otherwise virtual-hosted style
+//	access_key     static credentials; default to AWS_ACCESS_KEY_ID,
+//	secret_key     AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+//	session_token
+//	storage_class  optional x-amz-storage-class
+//
+// Files larger than MultipartThreshold are sent with a multipart upload,
+// which is aborted if a part fails.
+type S3Delivery struct {
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+
+	Endpoint     string
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+
+	// PartSize and MultipartThreshold default to DefaultS3PartSize and
+	// DefaultS3MultipartThreshold.
+	PartSize           int64
+	MultipartThreshold int64
+}
+
+type s3Target struct {
+	client                              *http.Client
+	base                                *url.URL // bucket URL, without a trailing slash
+	region, accessKey, secretKey, token string
+	prefix, storageClass                string
+	partSize, threshold                 int64
+}
+
+func (d S3Delivery) Deliver(ctx context.Context, target cronyx.DeliveryConfig, files []cronyx.OutputFile) error {
+	t, err := d.target(ctx, target)
+	if err != nil {
+		return cronyx.Permanent(fmt.Errorf("s3 delivery: %w", err))
+	}
+
+	info, _ := cronyx.RunInfoFromContext(ctx)
+	meta := map[string]string{}
+	if info.Job.ID != "" {
+		meta["x-amz-meta-job-id"] = info.Job.ID
+	}
+	if info.RunID != "" {
+		meta["x-amz-meta-run-id"] = info.RunID
+	}
+
+	for _, f := range files {
+		if err := t.upload(ctx, f, meta); err != nil {
+			return fmt.Errorf("s3 delivery: %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func (d S3Delivery) target(ctx context.Context, cfg cronyx.DeliveryConfig) (*s3Target, error) {
+	t := &s3Target{
+		client:       d.Client,
+		region:       valueOr(cfg["region"], valueOr(d.Region, "us-east-1")),
+		accessKey:    valueOr(cfg["access_key"], valueOr(d.AccessKey, os.Getenv("AWS_ACCESS_KEY_ID"))),
+		secretKey:    valueOr(cfg["secret_key"], valueOr(d.SecretKey, os.Getenv("AWS_SECRET_ACCESS_KEY"))),
+		token:        valueOr(cfg["session_token"], valueOr(d.SessionToken, os.Getenv("AWS_SESSION_TOKEN"))),
+		storageClass: cfg["storage_class"],
+		partSize:     d.PartSize,
+		threshold:    d.MultipartThreshold,
+	}
+	if t.client == nil {
+		t.client = http.DefaultClient
+	}
+	if t.partSize <= 0 {
+		t.partSize = DefaultS3PartSize
+	}
+	if t.partSize < minS3PartSize {
+		t.partSize = minS3PartSize
+	}
+	if t.threshold <= 0 {
+		t.threshold = DefaultS3MultipartThreshold
+	}
+
+	bucket := cfg["bucket"]
+	if bucket == "" {
+		return nil, fmt.Errorf("bucket is required")
+	}
+	if t.accessKey == "" || t.secretKey == "" {
+		return nil, fmt.Errorf("access_key and secret_key are required")
+	}
+
+	endpoint := valueOr(cfg["endpoint"], d.Endpoint)
+	pathStyle := endpoint != ""
+	if v := cfg["path_style"]; v != "" {
+		pathStyle = v == "true"
+	}
+	if endpoint == "" {
+		endpoint = "https://s3." + t.region + ".amazonaws.com"
+	}
+	base, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || base.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q", endpoint)
+	}
+	if pathStyle {
+		base.Path += "/" + bucket
+	} else {
+		base.Host = bucket + "." + base.Host
+	}
+	t.base = base
+
+	if p := cfg["prefix"]; p != "" {
+		if t.prefix, err = outputs.ExpandName(p, outputs.NewNameData(ctx, "")); err != nil {
+			return nil, fmt.Errorf("invalid prefix: %w", err)
+		}
+	}
+	return t, nil
+}
+
+func (t *s3Target) upload(ctx context.Context, f cronyx.OutputFile, meta map[string]string) error {
+	name := fileName(f)
+	key := name
+	if t.prefix != "" {
+		key = path.Join(t.prefix, name)
+	}
+
+	var src io.ReaderAt
+	var size int64
+	if f.Data != nil || f.Path == "" {
+		src, size = bytes.NewReader(f.Data), int64(len(f.Data))
+	} else {
+		file, err := os.Open(f.Path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		st, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		src, size = file, st.Size()
+	}
+
+	headers := map[string]string{"content-type": contentType(name)}
+	for k, v := range meta {
+		headers[k] = v
+	}
+	if t.storageClass != "" {
+		headers["x-amz-storage-class"] = t.storageClass
+	}
+
+	if size <= t.threshold {
+		body := make([]byte, size)
+		if _, err := src.ReadAt(body, 0); err != nil && err != io.EOF {
+			return err
+		}
+		_, err := t.do(ctx, http.MethodPut, key, nil, headers, body)
+		return err
+	}
+	return t.multipart(ctx, key, src, size, headers)
+}
+
+// multipart uploads src in parts, aborting the upload on failure.
+func (t *s3Target) multipart(ctx context.Context, key string, src io.ReaderAt, size int64, headers map[string]string) error {
+	partSize := t.partSize
+	if (size+partSize-1)/partSize > maxS3Parts {
+		partSize = (size + maxS3Parts - 1) / maxS3Parts
+	}
+
+	resp, err := t.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, headers, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.Unmarshal(resp, &initiated); err != nil || initiated.UploadID == "" {
+		return fmt.Errorf("failed to start multipart upload: no upload ID in response")
+	}
+	uploadID := initiated.UploadID
+
+	type part struct {
+		PartNumber int
+		ETag       string
+	}
+	var parts []part
+	err = func() error {
+		buf := make([]byte, partSize)
+		for n, off := 1, int64(0); off < size; n, off = n+1, off+partSize {
+			chunk := buf[:min(partSize, size-off)]
+			if _, err := src.ReadAt(chunk, off); err != nil && err != io.EOF {
+				return err
+			}
+			q := url.Values{"partNumber": {strconv.Itoa(n)}, "uploadId": {uploadID}}
+			etag, err := t.doETag(ctx, key, q, chunk)
+			if err != nil {
+				return fmt.Errorf("part %d: %w", n, err)
+			}
+			parts = append(parts, part{PartNumber: n, ETag: etag})
+		}
+
+		var body bytes.Buffer
+		body.WriteString("<CompleteMultipartUpload>")
+		for _, p := range parts {
+			fmt.Fprintf(&body, "<Part><PartNumber>%d</PartNumber><ETag>%s</ETag></Part>", p.PartNumber, xmlText(p.ETag))
+		}
+		body.WriteString("</CompleteMultipartUpload>")
+		resp, err := t.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, nil, body.Bytes())
+		if err != nil {
+			return err
+		}
+		// S3 may report a failed completion inside a 200 response.
+		if bytes.Contains(resp, []byte("<Error>")) {
+			return fmt.Errorf("failed to complete multipart upload: %s", resp)
+		}
+		return nil
+	}()
+	if err != nil {
+		abortCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+		t.do(abortCtx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, nil)
+		return err
+	}
+	return nil
+}
+
+func (t *s3Target) doETag(ctx context.Context, key string, q url.Values, body []byte) (string, error) {
+	req, err := t.request(ctx, http.MethodPut, key, q, nil, body)
+	if err != nil {
+		return "", err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse("s3", resp); err != nil {
+		return "", err
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.Header.Get("ETag"), nil
+}
+
+// do sends a signed request and returns the response body.
+func (t *s3Target) do(ctx context.Context, method, key string, q url.Values, headers map[string]string, body []byte) ([]byte, error) {
+	req, err := t.request(ctx, method, key, q, headers, body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse("s3", resp); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (t *s3Target) request(ctx context.Context, method, key string, q url.Values, headers map[string]string, body []byte) (*http.Request, error) {
+	u := *t.base
+	u.Path = t.base.Path + "/" + key
+	u.RawPath = t.base.EscapedPath() + "/" + s3Escape(key, false)
+	u.RawQuery = canonicalQuery(q)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, cronyx.Permanent(err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	signV4(req, body, t.region, t.accessKey, t.secretKey, t.token, time.Now())
+	return req, nil
+}
+
+// signV4 adds AWS Signature Version 4 headers for the s3 service.
+func signV4(req *http.Request, body []byte, region, accessKey, secretKey, token string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+
+	// Sign host plus every content and x-amz-* header.
+	signed := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, "x-amz-") || lk == "content-type" || lk == "content-md5" {
+			signed[lk] = strings.Join(strings.Fields(strings.Join(v, ",")), " ")
+		}
+	}
+	names := make([]string, 0, len(signed))
+	for k := range signed {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + signed[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes q sorted by key, as SigV4 requires.
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		vals := append([]string(nil), q[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Escape percent-encodes everything but unreserved characters (and "/"
+// unless encodeSlash is set).
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func xmlText(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
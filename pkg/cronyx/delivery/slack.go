@@ -0,0 +1,373 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx/outputs"
+	bf "github.com/russross/blackfriday/v2"
+)
+
+// Slack limits, see https://api.slack.com/reference/block-kit/blocks.
+const (
+	slackMaxBlocks      = 50
+	slackMaxHeaderText  = 150
+	slackMaxSectionText = 3000
+	slackMaxFallback    = 4000
+)
+
+// DefaultSlackRateLimitRetries is how often a 429 response is retried
+// when SlackDelivery.MaxRateLimitRetries is zero.
+const DefaultSlackRateLimitRetries = 3
+
+// SlackDelivery posts a summary of the run to a Slack incoming webhook:
+// job name, run time, row count and the generated files, formatted with
+// Block Kit. The report itself can be included, converted to Slack mrkdwn.
+//
+// Config keys:
+//
+//	webhook          incoming webhook URL (required)
+//	channel          channel override, for webhooks that allow it
+//	username         bot name override
+//	icon_emoji       bot icon override, e.g. ":bar_chart:"
+//	title            header text (default: the job name)
+//	include_content  "true" appends the rendered report
+//	max_content      character budget for the report (default 6000)
+//	link_base        URL prefix for linking outputs by file name;
+//	                 without it the local paths are listed
+//
+// Text is truncated to Slack's block limits. A 429 response is retried
+// after its Retry-After delay; other failures are returned as *StatusError.
+type SlackDelivery struct {
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+	// MaxRateLimitRetries bounds retries of 429 responses (default
+	// DefaultSlackRateLimitRetries; negative disables them).
+	MaxRateLimitRetries int
+}
+
+func (s SlackDelivery) Deliver(ctx context.Context, target cronyx.DeliveryConfig, files []cronyx.OutputFile) error {
+	webhook := target["webhook"]
+	if webhook == "" {
+		return cronyx.Permanent(fmt.Errorf("slack delivery: webhook is required"))
+	}
+	maxContent := 6000
+	if v := target["max_content"]; v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cronyx.Permanent(fmt.Errorf("slack delivery: invalid max_content %q", v))
+		}
+		maxContent = n
+	}
+
+	body, err := json.Marshal(slackMessage(ctx, target, files, maxContent))
+	if err != nil {
+		return fmt.Errorf("slack delivery: failed to encode message: %w", err)
+	}
+
+	retries := s.MaxRateLimitRetries
+	if retries == 0 {
+		retries = DefaultSlackRateLimitRetries
+	}
+	for attempt := 0; ; attempt++ {
+		err := s.post(ctx, webhook, body)
+		var statusErr *StatusError
+		if err == nil || !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests || attempt >= retries {
+			if err != nil {
+				return fmt.Errorf("slack delivery: %w", err)
+			}
+			return nil
+		}
+
+		wait := statusErr.RetryAfter
+		if wait <= 0 {
+			wait = time.Second
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("slack delivery: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+func (s SlackDelivery) post(ctx context.Context, webhook string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return cronyx.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse("slack", resp)
+}
+
+// slackMessage builds the webhook payload.
+func slackMessage(ctx context.Context, target cronyx.DeliveryConfig, files []cronyx.OutputFile, maxContent int) map[string]interface{} {
+	info, _ := cronyx.RunInfoFromContext(ctx)
+	title := valueOr(target["title"], valueOr(info.Job.Name, valueOr(info.Job.ID, "Report")))
+
+	var fields []map[string]interface{}
+	field := func(name, value string) {
+		fields = append(fields, mrkdwn("*"+name+"*\n"+value))
+	}
+	if !info.StartedAt.IsZero() {
+		field("Run time", slackDate(info.StartedAt))
+	}
+	if info.Report != nil {
+		field("Rows", strconv.Itoa(len(info.Report.Data.Rows)))
+	}
+	if info.RunID != "" {
+		field("Run", "`"+info.RunID+"`")
+	}
+	if info.Trigger != "" {
+		field("Trigger", string(info.Trigger))
+	}
+
+	blocks := []map[string]interface{}{{
+		"type": "header",
+		"text": map[string]interface{}{"type": "plain_text", "text": truncate(title, slackMaxHeaderText), "emoji": true},
+	}}
+	if len(fields) > 0 {
+		blocks = append(blocks, map[string]interface{}{"type": "section", "fields": fields})
+	}
+
+	if len(files) > 0 {
+		var lines []string
+		for _, f := range files {
+			lines = append(lines, "• "+slackFileLink(f, target["link_base"]))
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": mrkdwn(truncate("*Outputs*\n"+strings.Join(lines, "\n"), slackMaxSectionText)),
+		})
+	}
+
+	if target["include_content"] == "true" && info.Report != nil && maxContent > 0 {
+		content := truncate(reportMrkdwn(*info.Report), maxContent)
+		if content != "" {
+			blocks = append(blocks, map[string]interface{}{"type": "divider"})
+			for _, chunk := range splitText(content, slackMaxSectionText) {
+				if len(blocks) == slackMaxBlocks {
+					break
+				}
+				blocks = append(blocks, map[string]interface{}{"type": "section", "text": mrkdwn(chunk)})
+			}
+		}
+	}
+
+	msg := map[string]interface{}{
+		"text":   truncate(fmt.Sprintf("%s: %d file(s) generated", title, len(files)), slackMaxFallback),
+		"blocks": blocks,
+	}
+	for _, key := range []string{"channel", "username", "icon_emoji"} {
+		if v := target[key]; v != "" {
+			msg[key] = v
+		}
+	}
+	return msg
+}
+
+func mrkdwn(text string) map[string]interface{} {
+	return map[string]interface{}{"type": "mrkdwn", "text": text}
+}
+
+// slackDate uses Slack date formatting so readers see their local time.
+func slackDate(t time.Time) string {
+	return fmt.Sprintf("<!date^%d^{date_short_pretty} {time}|%s>", t.Unix(), t.UTC().Format("2006-01-02 15:04 UTC"))
+}
+
+func slackFileLink(f cronyx.OutputFile, base string) string {
+	if base != "" {
+		link := strings.TrimSuffix(base, "/") + "/" + url.PathEscape(f.Name)
+		return "<" + link + "|" + slackEscape(f.Name) + ">"
+	}
+	if f.Path != "" {
+		return "`" + f.Path + "`"
+	}
+	return slackEscape(f.Name)
+}
+
+// reportMrkdwn converts the rendered report into Slack mrkdwn.
+func reportMrkdwn(r cronyx.RenderedDoc) string {
+	format, _ := r.Meta["format"].(string)
+	if format != "" && format != "markdown" || r.Content == "" {
+		return slackEscape(outputs.PlainText(r))
+	}
+	doc := bf.New(bf.WithExtensions(bf.CommonExtensions)).Parse([]byte(r.Content))
+	return strings.TrimSpace(mrkdwnBlocks(doc, ""))
+}
+
+func mrkdwnBlocks(n *bf.Node, sep string) string {
+	var blocks []string
+	for c := n.FirstChild; c != nil; c = c.Next {
+		if b := mrkdwnBlock(c); b != "" {
+			blocks = append(blocks, b)
+		}
+	}
+	if sep == "" {
+		sep = "\n\n"
+	}
+	return strings.Join(blocks, sep)
+}
+
+func mrkdwnBlock(n *bf.Node) string {
+	switch n.Type {
+	case bf.Heading:
+		return "*" + strings.Trim(mrkdwnInline(n, false), "* ") + "*"
+	case bf.Paragraph:
+		return mrkdwnInline(n, false)
+	case bf.List:
+		var items []string
+		counter := 1
+		for item := n.FirstChild; item != nil; item = item.Next {
+			marker := "• "
+			if n.ListFlags&bf.ListTypeOrdered != 0 {
+				marker = strconv.Itoa(counter) + ". "
+				counter++
+			}
+			body := mrkdwnBlocks(item, "\n")
+			items = append(items, marker+strings.ReplaceAll(body, "\n", "\n    "))
+		}
+		return strings.Join(items, "\n")
+	case bf.BlockQuote:
+		return "> " + strings.ReplaceAll(mrkdwnBlocks(n, ""), "\n", "\n> ")
+	case bf.CodeBlock:
+		return "```\n" + slackEscape(strings.TrimRight(string(n.Literal), "\n")) + "\n```"
+	case bf.Table:
+		// Slack has no tables; keep the columns aligned in a code block.
+		return "```\n" + slackEscape(outputs.PlainText(cronyx.RenderedDoc{Content: tableMarkdown(n)})) + "```"
+	case bf.HorizontalRule:
+		return "───"
+	case bf.HTMLBlock:
+		return slackEscape(strings.TrimSpace(outputs.PlainText(cronyx.RenderedDoc{
+			Content: string(n.Literal), Meta: map[string]interface{}{"format": "html"},
+		})))
+	}
+	return mrkdwnBlocks(n, "")
+}
+
+func mrkdwnInline(n *bf.Node, inLink bool) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.Next {
+		switch c.Type {
+		case bf.Text:
+			b.WriteString(slackEscape(string(c.Literal)))
+		case bf.Code:
+			b.WriteString("`" + slackEscape(string(c.Literal)) + "`")
+		case bf.Softbreak:
+			b.WriteString("\n")
+		case bf.Hardbreak:
+			b.WriteString("\n")
+		case bf.Strong:
+			b.WriteString("*" + mrkdwnInline(c, inLink) + "*")
+		case bf.Emph:
+			b.WriteString("_" + mrkdwnInline(c, inLink) + "_")
+		case bf.Del:
+			b.WriteString("~" + mrkdwnInline(c, inLink) + "~")
+		case bf.Link, bf.Image:
+			text := mrkdwnInline(c, true)
+			dest := string(c.LinkData.Destination)
+			if inLink || dest == "" {
+				b.WriteString(text)
+			} else if text == "" {
+				b.WriteString("<" + dest + ">")
+			} else {
+				b.WriteString("<" + dest + "|" + text + ">")
+			}
+		case bf.HTMLSpan:
+		default:
+			b.WriteString(mrkdwnInline(c, inLink))
+		}
+	}
+	return b.String()
+}
+
+// tableMarkdown re-serialises a table node so outputs.PlainText can align it.
+func tableMarkdown(n *bf.Node) string {
+	var b strings.Builder
+	for section := n.FirstChild; section != nil; section = section.Next {
+		for row := section.FirstChild; row != nil; row = row.Next {
+			var cells, rules []string
+			for cell := row.FirstChild; cell != nil; cell = cell.Next {
+				text := strings.ReplaceAll(plainInline(cell), "|", `\|`)
+				cells = append(cells, text)
+				rule := "---"
+				if cell.Align == bf.TableAlignmentRight {
+					rule = "--:"
+				}
+				rules = append(rules, rule)
+			}
+			b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+			if section.Type == bf.TableHead {
+				b.WriteString("|" + strings.Join(rules, "|") + "|\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+func plainInline(n *bf.Node) string {
+	var b strings.Builder
+	n.Walk(func(c *bf.Node, entering bool) bf.WalkStatus {
+		if entering && (c.Type == bf.Text || c.Type == bf.Code) {
+			b.Write(c.Literal)
+		}
+		return bf.GoToNext
+	})
+	return b.String()
+}
+
+var slackEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+
+// truncate shortens s to at most max characters, marking the cut.
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:max-1]) + "…"
+}
+
+// splitText cuts s into chunks of at most max characters, preferring
+// paragraph and line boundaries.
+func splitText(s string, max int) []string {
+	var chunks []string
+	for utf8.RuneCountInString(s) > max {
+		runes := []rune(s)
+		head := string(runes[:max])
+		cut := strings.LastIndex(head, "\n\n")
+		if cut <= 0 {
+			cut = strings.LastIndex(head, "\n")
+		}
+		if cut <= 0 {
+			cut = len(head)
+		}
+		chunks = append(chunks, strings.TrimSpace(s[:cut]))
+		s = strings.TrimSpace(s[cut:])
+	}
+	if s != "" {
+		chunks = append(chunks, s)
+	}
+	return chunks
+}
@@ -0,0 +1,266 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+type slackPayload struct {
+	Text    string `json:"text"`
+	Channel string `json:"channel"`
+	Blocks  []struct {
+		Type string `json:"type"`
+		Text *struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"text"`
+		Fields []struct {
+			Text string `json:"text"`
+		} `json:"fields"`
+	} `json:"blocks"`
+}
+
+// texts returns the text of every block, header first.
+func (p slackPayload) texts() []string {
+	var out []string
+	for _, b := range p.Blocks {
+		if b.Text != nil {
+			out = append(out, b.Text.Text)
+		}
+		for _, f := range b.Fields {
+			out = append(out, f.Text)
+		}
+	}
+	return out
+}
+
+func slackServer(t *testing.T, handler func(w http.ResponseWriter, p slackPayload)) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p slackPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		handler(w, p)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSlackDeliveryMessage(t *testing.T) {
+	report := &cronyx.RenderedDoc{
+		Content: "# Sales\n\nTotal is **42** for [Q1](https://example.com/q1).\n\n- north\n- south\n",
+		Meta:    map[string]interface{}{"format": "markdown"},
+		Data:    cronyx.DataPayload{Rows: make([]map[string]interface{}, 3)},
+	}
+	ctx := cronyx.ContextWithRunInfo(context.Background(), cronyx.RunInfo{
+		RunID:     "run-7",
+		Job:       cronyx.ReportJob{ID: "daily", Name: "Daily Sales"},
+		Trigger:   cronyx.TriggerSchedule,
+		StartedAt: time.Unix(1700000000, 0),
+		Report:    report,
+	})
+	files := []cronyx.OutputFile{{Name: "sales report.pdf", Path: "/out/sales report.pdf"}}
+
+	tests := []struct {
+		name    string
+		cfg     cronyx.DeliveryConfig
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "summary",
+			cfg:  cronyx.DeliveryConfig{"channel": "#reports"},
+			want: []string{
+				"Daily Sales",
+				"*Rows*\n3",
+				"*Run*\n`run-7`",
+				"*Trigger*\nschedule",
+				"<!date^1700000000^",
+				"• `/out/sales report.pdf`",
+			},
+			notWant: []string{"Total is"},
+		},
+		{
+			name: "links and content",
+			cfg: cronyx.DeliveryConfig{
+				"title": "Custom", "include_content": "true", "link_base": "https://files.example.com/r/",
+			},
+			want: []string{
+				"Custom",
+				"<https://files.example.com/r/sales%20report.pdf|sales report.pdf>",
+				"*Sales*\n\nTotal is *42* for <https://example.com/q1|Q1>.\n\n• north\n• south",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got slackPayload
+			srv := slackServer(t, func(w http.ResponseWriter, p slackPayload) { got = p })
+			cfg := cronyx.DeliveryConfig{"webhook": srv.URL}
+			for k, v := range tt.cfg {
+				cfg[k] = v
+			}
+			if err := (SlackDelivery{}).Deliver(ctx, cfg, files); err != nil {
+				t.Fatal(err)
+			}
+
+			all := strings.Join(got.texts(), "\n---\n")
+			for _, w := range tt.want {
+				if !strings.Contains(all, w) {
+					t.Errorf("message lacks %q:\n%s", w, all)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(all, w) {
+					t.Errorf("message contains %q:\n%s", w, all)
+				}
+			}
+			if cfg["channel"] != "" && got.Channel != cfg["channel"] {
+				t.Errorf("channel = %q, want %q", got.Channel, cfg["channel"])
+			}
+			if got.Text == "" {
+				t.Error("no fallback text")
+			}
+		})
+	}
+}
+
+func TestSlackDeliveryLimits(t *testing.T) {
+	long := strings.Repeat("word ", 4000)
+	ctx := cronyx.ContextWithRunInfo(context.Background(), cronyx.RunInfo{
+		Job:    cronyx.ReportJob{Name: strings.Repeat("T", 300)},
+		Report: &cronyx.RenderedDoc{Content: long, Meta: map[string]interface{}{"format": "text"}},
+	})
+	var got slackPayload
+	srv := slackServer(t, func(w http.ResponseWriter, p slackPayload) { got = p })
+
+	cfg := cronyx.DeliveryConfig{"webhook": srv.URL, "include_content": "true", "max_content": "10000"}
+	if err := (SlackDelivery{}).Deliver(ctx, cfg, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Blocks) > slackMaxBlocks {
+		t.Errorf("%d blocks, limit %d", len(got.Blocks), slackMaxBlocks)
+	}
+	header := got.Blocks[0].Text.Text
+	if n := utf8.RuneCountInString(header); n != slackMaxHeaderText || !strings.HasSuffix(header, "…") {
+		t.Errorf("header has %d characters: %q", n, header)
+	}
+	var content int
+	for _, b := range got.Blocks[1:] {
+		if b.Text == nil {
+			continue
+		}
+		n := utf8.RuneCountInString(b.Text.Text)
+		if n > slackMaxSectionText {
+			t.Errorf("section has %d characters, limit %d", n, slackMaxSectionText)
+		}
+		content += n
+	}
+	if content > 10000 {
+		t.Errorf("content has %d characters, max_content 10000", content)
+	}
+}
+
+func TestSlackDeliveryRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "rate_limited", http.StatusTooManyRequests)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	if err := (SlackDelivery{}).Deliver(context.Background(), cronyx.DeliveryConfig{"webhook": srv.URL}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("%d requests, want 2", n)
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("retried after %v, want at least the Retry-After delay", waited)
+	}
+}
+
+func TestSlackDeliveryErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/limited":
+			w.Header().Set("Retry-After", "30")
+			http.Error(w, "rate_limited", http.StatusTooManyRequests)
+		case "/gone":
+			http.Error(w, "no_service", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		slack      SlackDelivery
+		cfg        cronyx.DeliveryConfig
+		wantStatus int
+		permanent  bool
+	}{
+		{"missing webhook", SlackDelivery{}, cronyx.DeliveryConfig{}, 0, true},
+		{"invalid max_content", SlackDelivery{}, cronyx.DeliveryConfig{"webhook": srv.URL, "max_content": "-1"}, 0, true},
+		{"not found", SlackDelivery{}, cronyx.DeliveryConfig{"webhook": srv.URL + "/gone"}, http.StatusNotFound, true},
+		{"rate limit retries disabled", SlackDelivery{MaxRateLimitRetries: -1}, cronyx.DeliveryConfig{"webhook": srv.URL + "/limited"}, http.StatusTooManyRequests, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.slack.Deliver(context.Background(), tt.cfg, nil)
+			if err == nil {
+				t.Fatal("Deliver succeeded")
+			}
+			var statusErr *StatusError
+			if tt.wantStatus != 0 {
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.wantStatus {
+					t.Errorf("err = %v, want status %d", err, tt.wantStatus)
+				}
+			}
+			if got := !cronyx.DefaultRetryable(err); got != tt.permanent {
+				t.Errorf("permanent = %v, want %v", got, tt.permanent)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-1", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.header != "" {
+			h.Set("Retry-After", tt.header)
+		}
+		if got := retryAfter(h, now); got != tt.want {
+			t.Errorf("retryAfter(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package delivery
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+// maxErrorBody bounds how much of a failed response is kept in a StatusError.
+const maxErrorBody = 1024
+
+// StatusError reports a non-2xx response from an HTTP delivery target.
+type StatusError struct {
+	Target     string // delivery type, e.g. "slack"
+	StatusCode int
+	Body       string        // start of the response body
+	RetryAfter time.Duration // from the Retry-After header, if any
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("%s: unexpected status %d %s", e.Target, e.StatusCode, http.StatusText(e.StatusCode))
+	if body := strings.TrimSpace(e.Body); body != "" {
+		msg += ": " + body
+	}
+	return msg
+}
+
+// Temporary reports whether the request may succeed if retried: rate
+// limits, timeouts and server errors.
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode == http.StatusRequestTimeout ||
+		e.StatusCode >= 500
+}
+
+// checkResponse returns nil for 2xx responses and a *StatusError otherwise,
+// marked cronyx.Permanent unless it is Temporary. It doesn't close the body.
+func checkResponse(target string, resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	err := &StatusError{
+		Target:     target,
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RetryAfter: retryAfter(resp.Header, time.Now()),
+	}
+	if !err.Temporary() {
+		return cronyx.Permanent(err)
+	}
+	return err
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(h http.Header, now time.Time) time.Duration {
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,255 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+const (
+	// DefaultWebhookEvent is the event name sent when none is configured.
+	DefaultWebhookEvent = "report.delivered"
+	// DefaultWebhookSignatureHeader carries the body signature.
+	DefaultWebhookSignatureHeader = "X-Cronyx-Signature"
+	// WebhookTimestampHeader carries the Unix time the signature covers.
+	WebhookTimestampHeader = "X-Cronyx-Timestamp"
+)
+
+// Errors returned by VerifyWebhookSignature.
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrExpiredSignature = errors.New("webhook timestamp outside the allowed window")
+)
+
+// WebhookDelivery POSTs a JSON description of the run to a URL.
+//
+// Config keys:
+//
+//	url               target URL (required)
+//	secret            HMAC key for signing; falls back to Secret
+//	content           "none" (default) sends file metadata only, "base64"
+//	                  embeds file contents in the JSON, "multipart" sends a
+//	                  multipart/form-data body with the JSON in the
+//	                  "payload" part and one "file" part per output
+//	event             event name (default DefaultWebhookEvent)
+//	signature_header  signature header name (default DefaultWebhookSignatureHeader)
+//	header.<Name>     extra request header, e.g. header.Authorization
+//
+// With a secret the request carries WebhookTimestampHeader and a signature
+// "sha256=<hex HMAC-SHA256 of timestamp + "." + body>", which receivers
+// check with VerifyWebhookSignature. Non-2xx responses are returned as
+// *StatusError.
+type WebhookDelivery struct {
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+	Secret string
+}
+
+// WebhookPayload is the JSON document sent by WebhookDelivery.
+type WebhookPayload struct {
+	Event string        `json:"event"`
+	Job   WebhookJob    `json:"job"`
+	Run   WebhookRun    `json:"run"`
+	Rows  *int          `json:"rows,omitempty"`
+	Files []WebhookFile `json:"files"`
+}
+
+type WebhookJob struct {
+	ID     string            `json:"id"`
+	Name   string            `json:"name,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type WebhookRun struct {
+	ID        string    `json:"id,omitempty"`
+	Trigger   string    `json:"trigger,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+type WebhookFile struct {
+	Name        string `json:"name"`
+	Path        string `json:"path,omitempty"`
+	Size        int    `json:"size"`
+	ContentType string `json:"content_type"`
+	SHA256      string `json:"sha256"`
+	Content     string `json:"content,omitempty"` // base64, with content=base64
+}
+
+func (w WebhookDelivery) Deliver(ctx context.Context, target cronyx.DeliveryConfig, files []cronyx.OutputFile) error {
+	url := target["url"]
+	if url == "" {
+		return cronyx.Permanent(fmt.Errorf("webhook delivery: url is required"))
+	}
+	mode := valueOr(target["content"], "none")
+	if mode != "none" && mode != "base64" && mode != "multipart" {
+		return cronyx.Permanent(fmt.Errorf("webhook delivery: unknown content mode %q", mode))
+	}
+
+	payload, data, err := webhookPayload(ctx, target, files, mode == "base64")
+	if err != nil {
+		return fmt.Errorf("webhook delivery: %w", err)
+	}
+	body, contentType, err := webhookBody(payload, files, data, mode == "multipart")
+	if err != nil {
+		return fmt.Errorf("webhook delivery: failed to encode payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return cronyx.Permanent(fmt.Errorf("webhook delivery: %w", err))
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "cronyx")
+	for k, v := range target {
+		if name, ok := strings.CutPrefix(k, "header."); ok && name != "" {
+			req.Header.Set(name, v)
+		}
+	}
+	if secret := valueOr(target["secret"], w.Secret); secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(WebhookTimestampHeader, ts)
+		req.Header.Set(valueOr(target["signature_header"], DefaultWebhookSignatureHeader), SignWebhook(secret, ts, body))
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook delivery: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := checkResponse("webhook", resp); err != nil {
+		return fmt.Errorf("webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// webhookPayload describes the run and its files. It also returns the file
+// contents, which are read once for hashing and reused for the body.
+func webhookPayload(ctx context.Context, target cronyx.DeliveryConfig, files []cronyx.OutputFile, embed bool) (WebhookPayload, [][]byte, error) {
+	info, _ := cronyx.RunInfoFromContext(ctx)
+	p := WebhookPayload{
+		Event: valueOr(target["event"], DefaultWebhookEvent),
+		Job:   WebhookJob{ID: info.Job.ID, Name: info.Job.Name, Labels: info.Job.Labels},
+		Run:   WebhookRun{ID: info.RunID, Trigger: string(info.Trigger), StartedAt: info.StartedAt},
+		Files: []WebhookFile{},
+	}
+	if info.Report != nil {
+		rows := len(info.Report.Data.Rows)
+		p.Rows = &rows
+	}
+
+	data := make([][]byte, len(files))
+	for i, f := range files {
+		b, err := fileData(f)
+		if err != nil {
+			return p, nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		data[i] = b
+		sum := sha256.Sum256(b)
+		wf := WebhookFile{
+			Name:        fileName(f),
+			Path:        f.Path,
+			Size:        len(b),
+			ContentType: contentType(fileName(f)),
+			SHA256:      hex.EncodeToString(sum[:]),
+		}
+		if embed {
+			wf.Content = base64.StdEncoding.EncodeToString(b)
+		}
+		p.Files = append(p.Files, wf)
+	}
+	return p, data, nil
+}
+
+// webhookBody encodes the payload as JSON, or as multipart/form-data with
+// the files attached.
+func webhookBody(p WebhookPayload, files []cronyx.OutputFile, data [][]byte, asMultipart bool) ([]byte, string, error) {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return nil, "", err
+	}
+	if !asMultipart {
+		return payload, "application/json", nil
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Disposition": {`form-data; name="payload"`},
+		"Content-Type":        {"application/json"},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	part.Write(payload)
+	for i, f := range files {
+		name := fileName(f)
+		// quotes names the way mime/multipart parses them, with RFC 2231
+		// encoding for non-ASCII names
+		disposition := mime.FormatMediaType("form-data", map[string]string{"name": "file", "filename": name})
+		if disposition == "" {
+			return nil, "", fmt.Errorf("can't encode file name %q", name)
+		}
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Disposition": {disposition},
+			"Content-Type":        {contentType(name)},
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		part.Write(data[i])
+	}
+	if err := mw.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), mw.FormDataContentType(), nil
+}
+
+// SignWebhook returns the signature header value for body sent at the
+// Unix time ts.
+func SignWebhook(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature checks a request sent by WebhookDelivery, given
+// its raw body and the timestamp and signature headers. Requests older (or
+// further in the future) than maxAge are rejected to prevent replays; a
+// zero maxAge skips that check.
+func VerifyWebhookSignature(secret string, body []byte, timestamp, signature string, maxAge time.Duration) error {
+	secs, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if maxAge > 0 {
+		age := time.Since(time.Unix(secs, 0))
+		if age > maxAge || age < -maxAge {
+			return ErrExpiredSignature
+		}
+	}
+	if !hmac.Equal([]byte(SignWebhook(secret, timestamp, body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package delivery
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+func TestSignWebhook(t *testing.T) {
+	// printf '1700000000.{"ok":true}' | openssl dgst -sha256 -hmac whsec
+	want := "sha256=8fff954f80a855c2adb24ba36c6f6d47d926481b848e42bd0cda22c562a7f436"
+	if got := SignWebhook("whsec", "1700000000", []byte(`{"ok":true}`)); got != want {
+		t.Errorf("SignWebhook = %s, want %s", got, want)
+	}
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"event":"report.delivered"}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name      string
+		secret    string
+		body      []byte
+		timestamp string
+		signature string
+		maxAge    time.Duration
+		want      error
+	}{
+		{"valid", "k", body, now, SignWebhook("k", now, body), 5 * time.Minute, nil},
+		{"tampered body", "k", []byte(`{"event":"other"}`), now, SignWebhook("k", now, body), 5 * time.Minute, ErrInvalidSignature},
+		{"wrong secret", "other", body, now, SignWebhook("k", now, body), 5 * time.Minute, ErrInvalidSignature},
+		{"timestamp not signed", "k", body, old, SignWebhook("k", now, body), 0, ErrInvalidSignature},
+		{"expired", "k", body, old, SignWebhook("k", old, body), 5 * time.Minute, ErrExpiredSignature},
+		{"no max age", "k", body, old, SignWebhook("k", old, body), 0, nil},
+		{"bad timestamp", "k", body, "yesterday", SignWebhook("k", "yesterday", body), 0, ErrInvalidSignature},
+		{"missing signature", "k", body, now, "", 0, ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyWebhookSignature(tt.secret, tt.body, tt.timestamp, tt.signature, tt.maxAge)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func webhookServer(t *testing.T) (*httptest.Server, *webhookRequest) {
+	t.Helper()
+	got := &webhookRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.header = r.Header
+		got.body, _ = io.ReadAll(r.Body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, got
+}
+
+func TestWebhookDeliverySignature(t *testing.T) {
+	tests := []struct {
+		name       string
+		webhook    WebhookDelivery
+		cfg        cronyx.DeliveryConfig
+		secret     string
+		wantHeader string
+	}{
+		{"config secret", WebhookDelivery{}, cronyx.DeliveryConfig{"secret": "a"}, "a", DefaultWebhookSignatureHeader},
+		{"field secret", WebhookDelivery{Secret: "b"}, cronyx.DeliveryConfig{}, "b", DefaultWebhookSignatureHeader},
+		{"config overrides field", WebhookDelivery{Secret: "b"}, cronyx.DeliveryConfig{"secret": "a"}, "a", DefaultWebhookSignatureHeader},
+		{"custom header", WebhookDelivery{}, cronyx.DeliveryConfig{"secret": "a", "signature_header": "X-Hub-Signature-256"}, "a", "X-Hub-Signature-256"},
+		{"unsigned", WebhookDelivery{}, cronyx.DeliveryConfig{}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, got := webhookServer(t)
+			cfg := cronyx.DeliveryConfig{"url": srv.URL, "content": "multipart"}
+			for k, v := range tt.cfg {
+				cfg[k] = v
+			}
+			files := []cronyx.OutputFile{{Name: "r.csv", Data: []byte("a\n1\n")}}
+			if err := tt.webhook.Deliver(context.Background(), cfg, files); err != nil {
+				t.Fatal(err)
+			}
+
+			ts := got.header.Get(WebhookTimestampHeader)
+			if tt.secret == "" {
+				if ts != "" || got.header.Get(DefaultWebhookSignatureHeader) != "" {
+					t.Errorf("unsigned request has signature headers: %v", got.header)
+				}
+				return
+			}
+			sig := got.header.Get(tt.wantHeader)
+			if sig == "" {
+				t.Fatalf("no %s header", tt.wantHeader)
+			}
+			if err := VerifyWebhookSignature(tt.secret, got.body, ts, sig, time.Minute); err != nil {
+				t.Errorf("VerifyWebhookSignature: %v", err)
+			}
+		})
+	}
+}
+
+func TestWebhookDeliveryContent(t *testing.T) {
+	files := []cronyx.OutputFile{
+		{Name: "report.csv", Path: "/out/report.csv", Data: []byte("a,b\n1,2\n")},
+	}
+	ctx := cronyx.ContextWithRunInfo(context.Background(), cronyx.RunInfo{
+		RunID:     "run-3",
+		Job:       cronyx.ReportJob{ID: "daily", Name: "Daily", Labels: map[string]string{"team": "ops"}},
+		Trigger:   cronyx.TriggerManual,
+		StartedAt: time.Unix(1700000000, 0).UTC(),
+		Report:    &cronyx.RenderedDoc{Data: cronyx.DataPayload{Rows: make([]map[string]interface{}, 2)}},
+	})
+
+	tests := []struct {
+		mode        string
+		wantContent string
+	}{
+		{"none", ""},
+		{"base64", base64.StdEncoding.EncodeToString(files[0].Data)},
+		{"multipart", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			srv, got := webhookServer(t)
+			cfg := cronyx.DeliveryConfig{"url": srv.URL, "content": tt.mode, "event": "report.ready", "header.Authorization": "Bearer t"}
+			if err := (WebhookDelivery{}).Deliver(ctx, cfg, files); err != nil {
+				t.Fatal(err)
+			}
+			if a := got.header.Get("Authorization"); a != "Bearer t" {
+				t.Errorf("Authorization = %q", a)
+			}
+
+			payloadJSON := got.body
+			var attached map[string]string
+			if tt.mode == "multipart" {
+				payloadJSON, attached = readWebhookMultipart(t, got.header.Get("Content-Type"), got.body)
+			} else if ct := got.header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+
+			var p WebhookPayload
+			if err := json.Unmarshal(payloadJSON, &p); err != nil {
+				t.Fatalf("payload: %v", err)
+			}
+			if p.Event != "report.ready" || p.Job.ID != "daily" || p.Job.Labels["team"] != "ops" ||
+				p.Run.ID != "run-3" || p.Run.Trigger != "manual" || !p.Run.StartedAt.Equal(time.Unix(1700000000, 0)) {
+				t.Errorf("payload = %+v", p)
+			}
+			if p.Rows == nil || *p.Rows != 2 {
+				t.Errorf("rows = %v, want 2", p.Rows)
+			}
+			if len(p.Files) != 1 {
+				t.Fatalf("files = %+v", p.Files)
+			}
+			f := p.Files[0]
+			sum := sha256.Sum256(files[0].Data)
+			if f.Name != "report.csv" || f.Path != "/out/report.csv" || f.Size != 8 || !strings.HasPrefix(f.ContentType, "text/csv") || f.SHA256 != hex.EncodeToString(sum[:]) {
+				t.Errorf("file = %+v", f)
+			}
+			if f.Content != tt.wantContent {
+				t.Errorf("content = %q, want %q", f.Content, tt.wantContent)
+			}
+			if tt.mode == "multipart" && attached["report.csv"] != string(files[0].Data) {
+				t.Errorf("attached files = %q", attached)
+			}
+		})
+	}
+}
+
+// readWebhookMultipart returns the payload part and the file parts by name.
+func readWebhookMultipart(t *testing.T, contentType string, body []byte) ([]byte, map[string]string) {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" {
+		t.Fatalf("Content-Type = %q", contentType)
+	}
+	var payload []byte
+	files := map[string]string{}
+	mr := multipart.NewReader(strings.NewReader(string(body)), params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(part)
+		switch part.FormName() {
+		case "payload":
+			payload = data
+		case "file":
+			files[part.FileName()] = string(data)
+		}
+	}
+	return payload, files
+}
+
+func TestWebhookMultipartFileNames(t *testing.T) {
+	names := []string{`sales "Q1".csv`, `back\slash.txt`, "übersicht 2025.pdf", "zero\u200bwidth.csv", "tab\there.csv", "plain.csv"}
+	var files []cronyx.OutputFile
+	var data [][]byte
+	for _, name := range names {
+		files = append(files, cronyx.OutputFile{Name: name})
+		data = append(data, []byte("data of "+name))
+	}
+	body, contentType, err := webhookBody(WebhookPayload{}, files, data, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, attached := readWebhookMultipart(t, contentType, body)
+	for _, name := range names {
+		if attached[name] != "data of "+name {
+			t.Errorf("file %q not attached under its name; got %q", name, attached)
+		}
+	}
+}
+
+func TestWebhookDeliveryErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gone":
+			http.Error(w, "gone", http.StatusGone)
+		case "/busy":
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		cfg        cronyx.DeliveryConfig
+		wantStatus int
+		permanent  bool
+	}{
+		{"missing url", cronyx.DeliveryConfig{}, 0, true},
+		{"bad content mode", cronyx.DeliveryConfig{"url": srv.URL, "content": "zip"}, 0, true},
+		{"client error", cronyx.DeliveryConfig{"url": srv.URL + "/gone"}, http.StatusGone, true},
+		{"server error", cronyx.DeliveryConfig{"url": srv.URL + "/busy"}, http.StatusServiceUnavailable, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := WebhookDelivery{}.Deliver(context.Background(), tt.cfg, nil)
+			if err == nil {
+				t.Fatal("Deliver succeeded")
+			}
+			var statusErr *StatusError
+			if tt.wantStatus != 0 && (!errors.As(err, &statusErr) || statusErr.StatusCode != tt.wantStatus) {
+				t.Errorf("err = %v, want status %d", err, tt.wantStatus)
+			}
+			if got := !cronyx.DefaultRetryable(err); got != tt.permanent {
+				t.Errorf("permanent = %v, want %v", got, tt.permanent)
+			}
+		})
+	}
+}
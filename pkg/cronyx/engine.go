@@ -178,8 +178,16 @@ package cronyx
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/robfig/cron/v3"
@@ -187,30 +195,141 @@ import (
 
 type Engine struct {
 	cronSched  *cron.Cron
+	cronParser cron.Parser
+	cronMode   CronMode
+	deferCheck bool // Config.DeferValidation
+	location   *time.Location
 	Loaders    map[string]DataLoader
 	Renderers  map[string]TemplateRenderer
 	Outputs    map[string]OutputGenerator
 	Deliveries map[string]DeliveryAdapter
 
-	jobQueue chan ReportJob
-	workers  int
-	stopCh   chan struct{}
+	jobQueue       chan queuedRun
+	workers        int
+	defaultTimeout time.Duration
+	logger         Logger
+	runStore       RunStore
+	metrics        *metrics // nil when metrics are disabled
+	busyWorkers    atomic.Int64
+	stopCh         chan struct{}
+
+	jobsMu sync.Mutex
+	jobs   map[string]*scheduledJob // registry of cron jobs by ID
+
+	overflow OverflowPolicy
+	queueMu  sync.Mutex
+	queued   map[string][]string // queued run IDs by job ID, oldest first
+	cancel   map[string]bool     // queued run IDs cancelled with CancelRun
+	pending  map[string][]string // queued and held run IDs by job ID, oldest first
+	slots    chan struct{}       // one token per queued or held run, see releaseSlot
+
+	// concurrency state, see admit
+	labelLimits map[string]int
+	runsMu      sync.Mutex
+	active      map[string]map[string]*activeRun // by job ID and run ID
+	held        []queuedRun                      // runs waiting for a policy or label slot
+	labelRuns   map[string]int                   // active runs by limited label
+	wake        chan struct{}
+	changed     chan struct{} // closed and replaced by wakeWorkers
+
+	// shutdown state
+	shutdownOnce sync.Once
+	workerWG     sync.WaitGroup
+	runCtx       context.Context // parent of run contexts, cancelled by Shutdown
+	cancelRuns   context.CancelCauseFunc
+	abandonedMu  sync.Mutex
+	abandoned    []JobRun
+}
+
+// queuedRun is a job waiting in the queue together with its run ID.
+type queuedRun struct {
+	runID   string
+	job     ReportJob
+	trigger Trigger
+	inline  bool // run by TestExecuteRun's caller; workers leave it alone
+	queued  bool // holds a queue slot
 }
 
+// NewEngine creates an engine with the given number of workers and default
+// settings for everything else.
 func NewEngine(workers int) *Engine {
+	return NewEngineWithConfig(&Config{Workers: workers})
+}
+
+// NewEngineWithConfig creates an engine from cfg. A nil cfg uses defaults.
+func NewEngineWithConfig(cfg *Config) *Engine {
+	var c Config
+	if cfg != nil {
+		c = *cfg
+	}
+	c = c.withDefaults()
+
+	parser := cronParser(c.CronMode)
 	e := &Engine{
-		cronSched:  cron.New(cron.WithSeconds()),
-		Loaders:    map[string]DataLoader{},
-		Renderers:  map[string]TemplateRenderer{},
-		Outputs:    map[string]OutputGenerator{},
-		Deliveries: map[string]DeliveryAdapter{},
-		jobQueue:   make(chan ReportJob, 100),
-		workers:    workers,
-		stopCh:     make(chan struct{}),
+		cronSched: cron.New(
+			cron.WithParser(parser),
+			cron.WithLocation(c.Location),
+			cron.WithLogger(cron.PrintfLogger(c.Logger)),
+		),
+		cronParser:     parser,
+		cronMode:       c.CronMode,
+		deferCheck:     c.DeferValidation,
+		location:       c.Location,
+		Loaders:        map[string]DataLoader{},
+		Renderers:      map[string]TemplateRenderer{},
+		Outputs:        map[string]OutputGenerator{},
+		Deliveries:     map[string]DeliveryAdapter{},
+		jobQueue:       make(chan queuedRun, c.QueueSize),
+		workers:        c.Workers,
+		defaultTimeout: c.DefaultTimeout,
+		logger:         c.Logger,
+		runStore:       c.RunStore,
+		stopCh:         make(chan struct{}),
+		jobs:           map[string]*scheduledJob{},
+		overflow:       c.Overflow,
+		queued:         map[string][]string{},
+		cancel:         map[string]bool{},
+		pending:        map[string][]string{},
+		slots:          make(chan struct{}, c.QueueSize),
+		labelLimits:    map[string]int{},
+		active:         map[string]map[string]*activeRun{},
+		labelRuns:      map[string]int{},
+		wake:           make(chan struct{}, c.Workers),
+		changed:        make(chan struct{}),
+	}
+	e.runCtx, e.cancelRuns = context.WithCancelCause(context.Background())
+	for key, n := range c.LabelLimits {
+		if n > 0 {
+			e.labelLimits[key] = n
+		}
+	}
+	if c.EnableMetrics {
+		e.metrics = newMetrics()
 	}
 	return e
 }
 
+// cronParser builds the schedule parser for the given mode.
+func cronParser(mode CronMode) cron.Parser {
+	fields := cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor
+	switch mode {
+	case CronStandard:
+	case CronSecondsOptional:
+		fields |= cron.SecondOptional
+	default:
+		fields |= cron.Second
+	}
+	return cron.NewParser(fields)
+}
+
+// jobTimeout returns the job's timeout, or the engine default when unset.
+func (e *Engine) jobTimeout(job ReportJob) time.Duration {
+	if job.Timeout > 0 {
+		return job.Timeout
+	}
+	return e.defaultTimeout
+}
+
 // Register helpers:
 func (e *Engine) RegisterLoader(name string, d DataLoader) {
 	e.Loaders[name] = d
@@ -231,97 +350,457 @@ func (e *Engine) RegisterDelivery(name string, d DeliveryAdapter) {
 // Start scheduler/workers
 func (e *Engine) Start() {
 	for i := 0; i < e.workers; i++ {
+		e.workerWG.Add(1)
 		go e.workerLoop(i)
 	}
 	e.cronSched.Start()
 }
 
-// Stop
+// Stop shuts the engine down, waiting for in-flight runs without a
+// deadline. Use Shutdown to bound the wait or to see abandoned runs.
 func (e *Engine) Stop() {
-	ctx := e.cronSched.Stop()
+	_, _ = e.Shutdown(context.Background())
+}
+
+// Shutdown stops the engine: the scheduler stops firing, Enqueue and
+// AddCronJob fail with ErrEngineStopped, and workers finish the run they
+// are executing but start no new ones. It waits for the in-flight runs,
+// including TestExecuteRun calls that have started, until ctx is done,
+// then cancels them and returns ctx.Err().
+//
+// Runs still queued or waiting for a concurrency slot, including
+// TestExecuteRun calls, are abandoned: they are recorded with status
+// RunAbandoned and returned. Shutdown may be called more than once; later
+// calls only wait for the in-flight runs and return no abandoned runs.
+func (e *Engine) Shutdown(ctx context.Context) ([]JobRun, error) {
+	first := false
+	e.shutdownOnce.Do(func() {
+		first = true
+		close(e.stopCh)
+	})
+
+	if first {
+		// scheduled triggers can't block on the queue once stopCh is
+		// closed, so this is quick
+		select {
+		case <-e.cronSched.Stop().Done():
+		case <-ctx.Done():
+		}
+	}
+
+	// TestExecuteRun adds its run to workerWG under runsMu while stopCh
+	// is open; taking the lock once orders those Adds before the Wait
+	e.runsMu.Lock()
+	e.runsMu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		e.workerWG.Wait()
+		close(drained)
+	}()
+	var err error
 	select {
+	case <-drained:
 	case <-ctx.Done():
-	case <-time.After(2 * time.Second):
+		e.cancelRuns(ErrEngineStopped)
+		err = ctx.Err()
 	}
-	close(e.stopCh)
-}
-
-func (e *Engine) AddCronJob(job ReportJob) error {
-	if job.Schedule == "" {
-		return errors.New("empty schedule")
+	if !first {
+		return nil, err
 	}
-	// enqueue on schedule
-	_, err := e.cronSched.AddFunc(job.Schedule, func() {
-		e.jobQueue <- job
-	})
-	return err
-}
 
-func (e *Engine) Enqueue(job ReportJob) {
-	e.jobQueue <- job
+drain:
+	for {
+		select {
+		case qr := <-e.jobQueue:
+			if e.dequeued(qr) {
+				e.recordUnstarted(qr, RunCancelled, ErrRunCancelled.Error())
+			} else {
+				e.abandon(qr)
+			}
+		default:
+			break drain
+		}
+	}
+	e.runsMu.Lock()
+	held := e.held
+	e.held = nil
+	e.runsMu.Unlock()
+	for _, qr := range held {
+		e.abandon(qr)
+	}
+	e.wakeWorkers()
+	e.abandonedMu.Lock()
+	abandoned := e.abandoned
+	e.abandoned = nil
+	e.abandonedMu.Unlock()
+	return abandoned, err
 }
 
 func (e *Engine) workerLoop(id int) {
+	defer e.workerWG.Done()
 	for {
+		// a closed stopCh wins over a non-empty queue
 		select {
-		case job := <-e.jobQueue:
-			ctx, cancel := context.WithTimeout(context.Background(), job.Timeout)
-			_ = e.execute(ctx, job)
-			cancel()
 		case <-e.stopCh:
 			return
+		default:
+		}
+		// held runs are older than anything in the queue
+		if ar := e.takeHeld(); ar != nil {
+			e.runActive(ar)
+			continue
+		}
+		select {
+		case qr := <-e.jobQueue:
+			ar, ok := e.admit(qr)
+			if !ok {
+				return
+			}
+			if ar != nil {
+				e.runActive(ar)
+			}
+		case <-e.wake:
+		case <-e.stopCh:
+			return
+		}
+	}
+}
+
+func (e *Engine) runActive(ar *activeRun) {
+	e.busyWorkers.Add(1)
+	_ = e.runJob(ar.ctx, ar.qr)
+	e.busyWorkers.Add(-1)
+	e.release(ar)
+}
+
+// abandon records a queued run that was dropped by Shutdown.
+func (e *Engine) abandon(qr queuedRun) {
+	run := e.recordUnstarted(qr, RunAbandoned, ErrEngineStopped.Error())
+	e.abandonedMu.Lock()
+	e.abandoned = append(e.abandoned, run)
+	e.abandonedMu.Unlock()
+}
+
+// recordUnstarted saves a run that never started and counts it in the
+// metrics.
+func (e *Engine) recordUnstarted(qr queuedRun, status RunStatus, reason string) JobRun {
+	now := time.Now()
+	run := JobRun{
+		ID:         qr.runID,
+		JobID:      qr.job.ID,
+		JobName:    qr.job.Name,
+		Trigger:    qr.trigger,
+		Status:     status,
+		StartedAt:  now,
+		FinishedAt: now,
+		Error:      reason,
+	}
+	e.releaseSlot(qr)
+	e.metrics.observeUnstarted(run.JobID, run.Status)
+	e.saveRun(run)
+	return run
+}
+
+// runJob executes a queued run under the job timeout and records it in the
+// run store. Failures are logged as well as returned.
+func (e *Engine) runJob(parent context.Context, qr queuedRun) error {
+	run := &JobRun{
+		ID:             qr.runID,
+		JobID:          qr.job.ID,
+		JobName:        qr.job.Name,
+		Trigger:        qr.trigger,
+		Status:         RunRunning,
+		StartedAt:      time.Now(),
+		StageDurations: map[Stage]time.Duration{},
+	}
+	e.saveRun(*run)
+
+	ctx, cancel := context.WithTimeout(parent, e.jobTimeout(qr.job))
+	defer cancel()
+	ctx = ContextWithRunInfo(ctx, RunInfo{
+		RunID:     run.ID,
+		Job:       qr.job,
+		Trigger:   run.Trigger,
+		StartedAt: run.StartedAt,
+	})
+
+	err := e.execute(ctx, run, qr.job)
+	if cause := context.Cause(parent); err != nil && cause != nil && cause != parent.Err() {
+		// e.g. cancelled by a ConcurrencyReplace run
+		err = fmt.Errorf("%w (%v)", err, cause)
+	}
+
+	run.FinishedAt = time.Now()
+	if err != nil {
+		run.Status = RunFailed
+		if cause := context.Cause(parent); errors.Is(cause, ErrRunCancelled) || errors.Is(cause, ErrEngineStopped) {
+			run.Status = RunCancelled
+		}
+		run.Error = err.Error()
+		e.logger.Printf("cronyx: %v", err)
+	} else {
+		run.Status = RunSucceeded
+	}
+	e.metrics.observeRun(run.JobID, run.Status, run.Duration())
+	e.saveRun(*run)
+	return err
+}
+
+// saveRun stores a snapshot of run. The map and slice are copied because
+// the caller keeps updating them while the stored run is being read.
+func (e *Engine) saveRun(run JobRun) {
+	run.StageDurations = maps.Clone(run.StageDurations)
+	run.Outputs = slices.Clone(run.Outputs)
+	if err := e.runStore.SaveRun(context.Background(), run); err != nil {
+		e.logger.Printf("cronyx: failed to save run %s: %v", run.ID, err)
+	}
+}
+
+// GetRun returns a run from the engine's run store.
+func (e *Engine) GetRun(ctx context.Context, runID string) (JobRun, error) {
+	return e.runStore.GetRun(ctx, runID)
+}
+
+// ListRuns queries the engine's run store, newest first.
+func (e *Engine) ListRuns(ctx context.Context, q RunQuery) ([]JobRun, error) {
+	return e.runStore.ListRuns(ctx, q)
+}
+
+// ValidateJob checks that every component the job refers to is registered.
+// The returned error is a *JobError wrapping one of the ErrNo* sentinels.
+func (e *Engine) ValidateJob(job ReportJob) error {
+	dsType := job.DataSource["type"]
+	if _, ok := e.Loaders[dsType]; !ok {
+		return &JobError{JobID: job.ID, Stage: StageLoad, Adapter: dsType, Err: ErrNoLoader}
+	}
+	if _, name, err := e.resolveRenderer(job); err != nil {
+		return &JobError{JobID: job.ID, Stage: StageRender, Adapter: name, Err: err}
+	}
+	for _, fmtName := range job.Outputs {
+		if _, ok := e.Outputs[fmtName]; !ok {
+			return &JobError{JobID: job.ID, Stage: StageOutput, Adapter: fmtName, Err: ErrNoOutput}
 		}
 	}
+	for _, dCfg := range job.Delivery {
+		if _, ok := e.Deliveries[dCfg["type"]]; !ok {
+			return &JobError{JobID: job.ID, Stage: StageDeliver, Adapter: dCfg["type"], Err: ErrNoDelivery}
+		}
+	}
+	return nil
 }
 
-func (e *Engine) execute(ctx context.Context, job ReportJob) error {
+func (e *Engine) execute(ctx context.Context, run *JobRun, job ReportJob) error {
+	// 1-4. load, render and generate outputs; retried as a whole
+	if err := job.Retry.validate(); err != nil {
+		return fmt.Errorf("cronyx: job %s: %w", job.ID, err)
+	}
+	var files []OutputFile
+	var rendered RenderedDoc
+	err := retry(ctx, job.Retry, func(attempt int) error {
+		run.Attempts = attempt
+		var err error
+		files, rendered, err = e.produce(ctx, run, job)
+		return err
+	}, e.logRetry(run, "run"))
+	if err != nil {
+		return err
+	}
+
+	// delivery adapters can use the rendered report, e.g. as a message body
+	if info, ok := RunInfoFromContext(ctx); ok {
+		info.Report = &rendered
+		ctx = ContextWithRunInfo(ctx, info)
+	}
+
+	// 5. delivery; retries reuse the files generated above
+	for _, dCfg := range job.Delivery {
+		dtype := dCfg["type"]
+		adapter, ok := e.Deliveries[dtype]
+		if !ok {
+			return &JobError{JobID: job.ID, RunID: run.ID, Stage: StageDeliver, Adapter: dtype, Err: ErrNoDelivery}
+		}
+		policy, err := deliveryRetryPolicy(job, dCfg)
+		if err != nil {
+			return &JobError{JobID: job.ID, RunID: run.ID, Stage: StageDeliver, Adapter: dtype, Err: err}
+		}
+		if err := ctx.Err(); err != nil {
+			return &JobError{JobID: job.ID, RunID: run.ID, Stage: StageDeliver, Adapter: dtype, Err: err}
+		}
+		err = retry(ctx, policy, func(int) error {
+			start := time.Now()
+			err := adapter.Deliver(ctx, dCfg, files)
+			e.timeStage(run, StageDeliver, start)
+			return err
+		}, e.logRetry(run, "delivery "+dtype))
+		if err != nil {
+			return &JobError{JobID: job.ID, RunID: run.ID, Stage: StageDeliver, Adapter: dtype, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// produce runs the load, render and output stages of a job and returns
+// the generated files and the rendered document.
+func (e *Engine) produce(ctx context.Context, run *JobRun, job ReportJob) ([]OutputFile, RenderedDoc, error) {
+	stageErr := func(stage Stage, adapter string, err error) error {
+		return &JobError{JobID: job.ID, RunID: run.ID, Stage: stage, Adapter: adapter, Err: err}
+	}
+	run.Outputs = nil
+
 	// 1. find loader (based on type in DataSource)
 	dsType := job.DataSource["type"]
 	loader, ok := e.Loaders[dsType]
 	if !ok {
-		return fmt.Errorf("no loader for type %s", dsType)
+		return nil, RenderedDoc{}, stageErr(StageLoad, dsType, ErrNoLoader)
 	}
 
 	// 2. load
+	start := time.Now()
 	data, err := loader.Load(ctx, job.DataSource)
+	e.timeStage(run, StageLoad, start)
 	if err != nil {
-		return err
+		return nil, RenderedDoc{}, stageErr(StageLoad, dsType, err)
 	}
+	e.metrics.addRows(job.ID, len(data.Rows))
 
-	// 3. render (pick renderer from template type; we'll assume "markdown")
-	renderer := e.Renderers["markdown"]
-	rendered, err := renderer.Render(ctx, job.TemplatePath, data)
+	// 3. render (renderer from job, or inferred from template extension)
+	renderer, rendererName, err := e.resolveRenderer(job)
 	if err != nil {
-		return err
+		return nil, RenderedDoc{}, stageErr(StageRender, rendererName, err)
+	}
+	// stop between stages when the run was cancelled, even if the
+	// adapter before didn't notice
+	if err := ctx.Err(); err != nil {
+		return nil, RenderedDoc{}, stageErr(StageRender, rendererName, err)
 	}
+	start = time.Now()
+	var rendered RenderedDoc
+	if cr, ok := renderer.(ConfigurableRenderer); ok {
+		rendered, err = cr.RenderWithOptions(ctx, job.TemplatePath, data, job.RendererOptions)
+	} else {
+		rendered, err = renderer.Render(ctx, job.TemplatePath, data)
+	}
+	e.timeStage(run, StageRender, start)
+	if err != nil {
+		return nil, RenderedDoc{}, stageErr(StageRender, rendererName, err)
+	}
+	rendered.Data = data
 
-	// 4. outputs
+	// 4. outputs; a failure removes the files this attempt already wrote
+	// so a retry doesn't collide with them
 	var files []OutputFile
+	outputErr := func(fmtName string, err error) ([]OutputFile, RenderedDoc, error) {
+		e.removeOutputs(run, files)
+		run.Outputs = nil
+		return nil, RenderedDoc{}, stageErr(StageOutput, fmtName, err)
+	}
 	for _, fmtName := range job.Outputs {
 		outGen, ok := e.Outputs[fmtName]
 		if !ok {
-			return fmt.Errorf("no output generator for %s", fmtName)
+			return outputErr(fmtName, ErrNoOutput)
 		}
+		if err := ctx.Err(); err != nil {
+			return outputErr(fmtName, err)
+		}
+		start = time.Now()
 		f, err := outGen.Generate(ctx, rendered, fmtName)
+		e.timeStage(run, StageOutput, start)
 		if err != nil {
-			return err
+			return outputErr(fmtName, err)
 		}
+		e.metrics.addBytes(fmtName, outputSize(f))
 		files = append(files, f)
+		run.Outputs = append(run.Outputs, OutputFile{Name: f.Name, Path: f.Path})
 	}
+	return files, rendered, nil
+}
 
-	// 5. delivery
-	for _, dCfg := range job.Delivery {
-		dtype := dCfg["type"]
-		adapter, ok := e.Deliveries[dtype]
-		if !ok {
-			return fmt.Errorf("no delivery adapter for %s", dtype)
+// removeOutputs deletes generated files that live on the local disk.
+// Files kept only in memory or stored remotely are left alone.
+func (e *Engine) removeOutputs(run *JobRun, files []OutputFile) {
+	for _, f := range files {
+		if f.Path == "" || strings.Contains(f.Path, "://") {
+			continue
 		}
-		if err := adapter.Deliver(ctx, dCfg, files); err != nil {
-			return err
+		if err := os.Remove(f.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			e.logger.Printf("cronyx: job %s (run %s): removing %s: %v", run.JobID, run.ID, f.Path, err)
 		}
 	}
+}
 
-	return nil
+// timeStage adds the time since start to the run's stage duration.
+func (e *Engine) timeStage(run *JobRun, stage Stage, start time.Time) {
+	d := time.Since(start)
+	run.StageDurations[stage] += d
+	e.metrics.observeStage(stage, d)
+}
+
+// deliveryRetryPolicy returns the retry policy for one delivery: its own
+// retry_* settings if present, otherwise the job's policy.
+func deliveryRetryPolicy(job ReportJob, dCfg DeliveryConfig) (*RetryPolicy, error) {
+	p, err := ParseRetryPolicy(dCfg)
+	if err != nil || p == nil {
+		return job.Retry, err
+	}
+	if job.Retry != nil {
+		p.Retryable = job.Retry.Retryable
+	}
+	return p, nil
+}
+
+// logRetry returns a retry callback that logs the failed attempt.
+func (e *Engine) logRetry(run *JobRun, what string) func(int, time.Duration, error) {
+	return func(attempt int, wait time.Duration, err error) {
+		e.logger.Printf("cronyx: job %s (run %s): %s attempt %d failed, retrying in %v: %v",
+			run.JobID, run.ID, what, attempt, wait.Round(time.Millisecond), err)
+	}
+}
+
+// outputSize returns the size of a generated file, from its data or, failing
+// that, from the file on disk.
+func outputSize(f OutputFile) int64 {
+	if len(f.Data) > 0 {
+		return int64(len(f.Data))
+	}
+	if fi, err := os.Stat(f.Path); err == nil {
+		return fi.Size()
+	}
+	return 0
+}
+
+// rendererExtensions maps template file extensions to renderer names.
+var rendererExtensions = map[string]string{
+	".md":       "markdown",
+	".markdown": "markdown",
+	".html":     "html",
+	".htm":      "html",
+	".txt":      "text",
+}
+
+// resolveRenderer picks the renderer for a job: the explicitly named one if
+// set, otherwise the one matching the template extension. It also returns
+// the renderer name it looked for.
+func (e *Engine) resolveRenderer(job ReportJob) (TemplateRenderer, string, error) {
+	if job.Renderer != "" {
+		r, ok := e.Renderers[job.Renderer]
+		if !ok {
+			return nil, job.Renderer, fmt.Errorf("%w: %q is not registered", ErrNoRenderer, job.Renderer)
+		}
+		return r, job.Renderer, nil
+	}
+
+	ext := strings.ToLower(filepath.Ext(job.TemplatePath))
+	name, ok := rendererExtensions[ext]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: cannot infer renderer from template %q", ErrNoRenderer, job.TemplatePath)
+	}
+	r, ok := e.Renderers[name]
+	if !ok {
+		return nil, name, fmt.Errorf("%w: %q (inferred from %q) is not registered", ErrNoRenderer, name, job.TemplatePath)
+	}
+	return r, name, nil
 }
 
 func (e *Engine) GetLoaders() map[string]DataLoader {
@@ -340,12 +819,36 @@ func (e *Engine) GetDeliveries() map[string]DeliveryAdapter {
 	return e.Deliveries
 }
 
+// TestExecute runs job once in the calling goroutine and returns its
+// error. See TestExecuteRun.
 func (e *Engine) TestExecute(ctx context.Context, job ReportJob) error {
-	fmt.Printf("=== Executing job: %s ===\n", job.Name)
+	_, err := e.TestExecuteRun(ctx, job)
+	return err
+}
 
-	// Add timeout to context
-	ctx, cancel := context.WithTimeout(ctx, job.Timeout)
-	defer cancel()
+// TestExecuteRun runs job once in the calling goroutine, without going
+// through the queue, and returns the run ID and the run's error. It does
+// not need Start. The run obeys the job's concurrency policy and the
+// label limits like a queued run: a skipped run fails with ErrRunSkipped
+// and a held run waits until it may start or ctx is done. While it runs
+// it can be cancelled with CancelRun (its ID is in the run store as
+// RunRunning) or CancelJob.
+func (e *Engine) TestExecuteRun(ctx context.Context, job ReportJob) (string, error) {
+	qr := queuedRun{runID: generateRunID(), job: job, trigger: TriggerTest, inline: true}
+	ar, err := e.admitInline(ctx, qr)
+	if err != nil {
+		return qr.runID, err
+	}
+	defer e.workerWG.Done()
+	defer e.release(ar)
+	stop := context.AfterFunc(ctx, func() { ar.cancel(context.Cause(ctx)) })
+	defer stop()
+	return qr.runID, e.runJob(ar.ctx, qr)
+}
 
-	return e.execute(ctx, job)
+// generateRunID creates a random run ID
+func generateRunID() string {
+	bytes := make([]byte, 6)
+	rand.Read(bytes)
+	return fmt.Sprintf("run-%x", bytes)
 }
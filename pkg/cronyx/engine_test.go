@@ -0,0 +1,317 @@
+package cronyx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingLoader reports the run ID of every Load on started and blocks
+// until release is closed or the run's context is done.
+type blockingLoader struct {
+	started     chan string
+	release     chan struct{}
+	releaseOnce sync.Once
+}
+
+func (l *blockingLoader) Load(ctx context.Context, cfg DataSourceConfig) (DataPayload, error) {
+	info, _ := RunInfoFromContext(ctx)
+	l.started <- info.RunID
+	select {
+	case <-l.release:
+		return DataPayload{}, nil
+	case <-ctx.Done():
+		return DataPayload{}, ctx.Err()
+	}
+}
+
+func (l *blockingLoader) releaseAll() { l.releaseOnce.Do(func() { close(l.release) }) }
+
+// waitStarted returns the ID of the next run that reached the loader.
+func (l *blockingLoader) waitStarted(t *testing.T) string {
+	t.Helper()
+	select {
+	case id := <-l.started:
+		return id
+	case <-time.After(5 * time.Second):
+		t.Fatal("no run started")
+		return ""
+	}
+}
+
+type nopRenderer struct{}
+
+func (nopRenderer) Render(ctx context.Context, tplPath string, data DataPayload) (RenderedDoc, error) {
+	return RenderedDoc{}, nil
+}
+
+// newTestEngine returns a started engine whose "block" loader is l. The
+// engine is shut down when the test ends.
+func newTestEngine(t *testing.T, cfg Config) (*Engine, *blockingLoader) {
+	t.Helper()
+	cfg.Logger = log.New(io.Discard, "", 0)
+	e := NewEngineWithConfig(&cfg)
+	l := &blockingLoader{started: make(chan string, 16), release: make(chan struct{})}
+	e.RegisterLoader("block", l)
+	e.RegisterRenderer("text", nopRenderer{})
+	e.Start()
+	t.Cleanup(func() {
+		l.releaseAll()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		e.Shutdown(ctx)
+	})
+	return e, l
+}
+
+func testJob(id string, policy ConcurrencyPolicy) ReportJob {
+	return ReportJob{ID: id, TemplatePath: "report.txt", DataSource: DataSourceConfig{"type": "block"}, Concurrency: policy}
+}
+
+func mustEnqueue(t *testing.T, e *Engine, job ReportJob) string {
+	t.Helper()
+	id, err := e.Enqueue(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// waitFor polls cond until it holds or the test times out.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// waitRun waits until the run is recorded with status and returns it.
+func waitRun(t *testing.T, e *Engine, runID string, status RunStatus) JobRun {
+	t.Helper()
+	var run JobRun
+	waitFor(t, "run "+runID+" to be "+string(status), func() bool {
+		var err error
+		run, err = e.GetRun(context.Background(), runID)
+		return err == nil && run.Status == status
+	})
+	return run
+}
+
+func heldCount(e *Engine) int {
+	e.runsMu.Lock()
+	defer e.runsMu.Unlock()
+	return len(e.held)
+}
+
+func stopped(e *Engine) bool {
+	select {
+	case <-e.stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+func runIDs(runs []JobRun) []string {
+	var ids []string
+	for _, r := range runs {
+		ids = append(ids, r.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func sorted(ids ...string) []string {
+	sort.Strings(ids)
+	return ids
+}
+
+func TestShutdownDrainsAndAbandons(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		policy  ConcurrencyPolicy
+		waiting int // runs enqueued behind the running one
+		held    int // of which held by the policy when Shutdown is called
+	}{
+		{name: "queued runs", workers: 1, waiting: 2},
+		{name: "held run", workers: 2, policy: ConcurrencyQueueOne, waiting: 1, held: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, l := newTestEngine(t, Config{Workers: tt.workers})
+			job := testJob("daily", tt.policy)
+			running := mustEnqueue(t, e, job)
+			l.waitStarted(t)
+			var waiting []string
+			for i := 0; i < tt.waiting; i++ {
+				waiting = append(waiting, mustEnqueue(t, e, job))
+			}
+			waitFor(t, "held runs", func() bool { return heldCount(e) == tt.held })
+
+			type result struct {
+				abandoned []JobRun
+				err       error
+			}
+			done := make(chan result)
+			go func() {
+				abandoned, err := e.Shutdown(context.Background())
+				done <- result{abandoned, err}
+			}()
+			waitFor(t, "shutdown to start", func() bool { return stopped(e) })
+			if _, err := e.Enqueue(job); !errors.Is(err, ErrEngineStopped) {
+				t.Errorf("Enqueue after Shutdown: err = %v, want ErrEngineStopped", err)
+			}
+			select {
+			case <-done:
+				t.Fatal("Shutdown returned before the in-flight run ended")
+			case <-time.After(20 * time.Millisecond):
+			}
+
+			l.releaseAll()
+			res := <-done
+			if res.err != nil {
+				t.Fatalf("Shutdown: %v", res.err)
+			}
+			waitRun(t, e, running, RunSucceeded)
+			for _, run := range res.abandoned {
+				if run.Status != RunAbandoned || run.Error != ErrEngineStopped.Error() {
+					t.Errorf("abandoned run %s: status %s, error %q", run.ID, run.Status, run.Error)
+				}
+				waitRun(t, e, run.ID, RunAbandoned)
+			}
+			if got, want := runIDs(res.abandoned), sorted(waiting...); !equalStrings(got, want) {
+				t.Errorf("abandoned %v, want %v", got, want)
+			}
+			if n := heldCount(e); n != 0 {
+				t.Errorf("%d runs still held", n)
+			}
+
+			// later calls don't report the runs again
+			again, err := e.Shutdown(context.Background())
+			if err != nil || len(again) != 0 {
+				t.Errorf("second Shutdown = %v, %v", again, err)
+			}
+		})
+	}
+}
+
+func TestShutdownDeadlineCancelsRuns(t *testing.T) {
+	e, l := newTestEngine(t, Config{Workers: 1})
+	running := mustEnqueue(t, e, testJob("daily", ConcurrencyAllow))
+	l.waitStarted(t)
+	queued := mustEnqueue(t, e, testJob("daily", ConcurrencyAllow))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	abandoned, err := e.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown: err = %v, want DeadlineExceeded", err)
+	}
+	if got := runIDs(abandoned); !equalStrings(got, []string{queued}) {
+		t.Errorf("abandoned %v, want [%s]", got, queued)
+	}
+
+	// a later Shutdown waits for the cancelled run to return
+	if _, err := e.Shutdown(context.Background()); err != nil {
+		t.Fatalf("second Shutdown: %v", err)
+	}
+	run := waitRun(t, e, running, RunCancelled)
+	if !errors.Is(context.Cause(e.runCtx), ErrEngineStopped) {
+		t.Errorf("run context cause = %v", context.Cause(e.runCtx))
+	}
+	if run.FinishedAt.IsZero() || run.Error == "" {
+		t.Errorf("cancelled run = %+v", run)
+	}
+}
+
+func TestShutdownAbandonsWaitingTestExecute(t *testing.T) {
+	e, l := newTestEngine(t, Config{Workers: 1})
+	job := testJob("daily", ConcurrencyQueueOne)
+	mustEnqueue(t, e, job)
+	l.waitStarted(t)
+
+	type result struct {
+		runID string
+		err   error
+	}
+	inline := make(chan result)
+	go func() {
+		id, err := e.TestExecuteRun(context.Background(), job)
+		inline <- result{id, err}
+	}()
+	waitFor(t, "held run", func() bool { return heldCount(e) == 1 })
+
+	done := make(chan []JobRun)
+	go func() {
+		abandoned, _ := e.Shutdown(context.Background())
+		done <- abandoned
+	}()
+	// the test run gives up without waiting for the in-flight run
+	res := <-inline
+	if !errors.Is(res.err, ErrEngineStopped) {
+		t.Errorf("TestExecuteRun: err = %v, want ErrEngineStopped", res.err)
+	}
+	l.releaseAll()
+	if got := runIDs(<-done); !equalStrings(got, []string{res.runID}) {
+		t.Errorf("abandoned %v, want [%s]", got, res.runID)
+	}
+	waitRun(t, e, res.runID, RunAbandoned)
+
+	if _, err := e.TestExecuteRun(context.Background(), job); !errors.Is(err, ErrEngineStopped) {
+		t.Errorf("TestExecuteRun after Shutdown: err = %v, want ErrEngineStopped", err)
+	}
+}
+
+func TestShutdownWaitsForTestExecute(t *testing.T) {
+	e, l := newTestEngine(t, Config{})
+	inline := make(chan error)
+	go func() {
+		_, err := e.TestExecuteRun(context.Background(), testJob("daily", ConcurrencyAllow))
+		inline <- err
+	}()
+	runID := l.waitStarted(t)
+
+	done := make(chan error)
+	go func() {
+		_, err := e.Shutdown(context.Background())
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("Shutdown returned %v while a test run was executing", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	l.releaseAll()
+	if err := <-inline; err != nil {
+		t.Errorf("TestExecuteRun: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Shutdown: %v", err)
+	}
+	if run, _ := e.GetRun(context.Background(), runID); run.Status != RunSucceeded {
+		t.Errorf("run = %+v, want it finished before Shutdown returned", run)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package cronyx
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoLoader is returned when no loader is registered for a data source type.
+	ErrNoLoader = errors.New("no loader")
+	// ErrNoRenderer is returned when no registered renderer matches a job.
+	ErrNoRenderer = errors.New("no renderer")
+	// ErrNoOutput is returned when no output generator is registered for a format.
+	ErrNoOutput = errors.New("no output generator")
+	// ErrNoDelivery is returned when no delivery adapter is registered for a type.
+	ErrNoDelivery = errors.New("no delivery adapter")
+	// ErrInvalidSchedule is returned for empty or unparsable cron expressions.
+	ErrInvalidSchedule = errors.New("invalid schedule")
+	// ErrQueueFull is returned when the job queue has no free slot.
+	ErrQueueFull = errors.New("job queue full")
+	// ErrEngineStopped is returned once the engine has been stopped.
+	ErrEngineStopped = errors.New("engine stopped")
+	// ErrDuplicateJob is returned when scheduling a job whose ID is taken.
+	ErrDuplicateJob = errors.New("duplicate job ID")
+	// ErrJobNotFound is returned for IDs that aren't in the job registry.
+	ErrJobNotFound = errors.New("job not found")
+	// ErrRunNotFound is returned by a RunStore for unknown run IDs, and by
+	// CancelRun for runs that are not queued, waiting or running.
+	ErrRunNotFound = errors.New("run not found")
+	// ErrRunCancelled is the cause of runs cancelled with CancelRun,
+	// CancelJob or ConcurrencyReplace.
+	ErrRunCancelled = errors.New("run cancelled")
+	// ErrRunSkipped is returned by TestExecuteRun when the job's
+	// concurrency policy skips the run.
+	ErrRunSkipped = errors.New("run skipped")
+)
+
+// Stage identifies a step of the report pipeline.
+type Stage string
+
+const (
+	StageLoad    Stage = "load"
+	StageRender  Stage = "render"
+	StageOutput  Stage = "output"
+	StageDeliver Stage = "deliver"
+)
+
+// JobError describes a failure in one pipeline stage of a job run. Use
+// errors.As to inspect it and errors.Is to match the underlying cause.
+type JobError struct {
+	JobID   string
+	RunID   string // empty when the job was validated rather than run
+	Stage   Stage
+	Adapter string // loader type, renderer name, output format or delivery type
+	Err     error
+}
+
+func (e *JobError) Error() string {
+	job := e.JobID
+	if e.RunID != "" {
+		job = fmt.Sprintf("%s (run %s)", e.JobID, e.RunID)
+	}
+	return fmt.Sprintf("job %s: %s %q: %v", job, e.Stage, e.Adapter, e.Err)
+}
+
+func (e *JobError) Unwrap() error {
+	return e.Err
+}
@@ -13,6 +13,12 @@ type TemplateRenderer interface {
 	Render(ctx context.Context, tplPath string, data DataPayload) (RenderedDoc, error)
 }
 
+// ConfigurableRenderer is an optional extension for renderers that accept
+// the per-job RendererOptions.
+type ConfigurableRenderer interface {
+	RenderWithOptions(ctx context.Context, tplPath string, data DataPayload, opts RendererOptions) (RenderedDoc, error)
+}
+
 // OutputGenerator: takes rendered docs to produce final files (pdf, xlsx, csv)
 type OutputGenerator interface {
 	Generate(ctx context.Context, rendered RenderedDoc, format string) (OutputFile, error)
@@ -26,18 +32,28 @@ type DeliveryAdapter interface {
 // Minimal data structures
 type DataPayload struct {
 	// generic bag — implementers decide representation
-	Rows []map[string]interface{}
-	Raw  []byte
+	Rows    []map[string]interface{}
+	Columns []string  // column order, when the source defines one
+	Sets    []DataSet // optional additional named data sets
+	Raw     []byte
+}
+
+// DataSet is a named table, used when a loader returns more than one.
+type DataSet struct {
+	Name    string
+	Columns []string
+	Rows    []map[string]interface{}
 }
 
 type RenderedDoc struct {
 	HTML    string                 // for HTML→PDF pipelines
 	Content string                 // raw content
-	Meta    map[string]interface{} // metadata
+	Meta    map[string]interface{} // metadata; "format" names the Content syntax (markdown, html, text)
+	Data    DataPayload            // the loaded data, attached by the engine
 }
 
 type OutputFile struct {
-	Name string
-	Path string // local path or s3:// uri depending on storage adapter
-	Data []byte // optional
+	Name string `json:"name"`
+	Path string `json:"path"`           // local path or s3:// uri depending on storage adapter
+	Data []byte `json:"data,omitempty"` // optional
 }
@@ -6,15 +6,29 @@ type ReportJob struct {
 	ID           string
 	Name         string
 	TemplatePath string
-	DataSource   DataSourceConfig
-	Outputs      []string
-	Schedule     string
-	Delivery     []DeliveryConfig
-	Timeout      time.Duration
-	Labels       map[string]string
+	// Renderer names the registered renderer to use. When empty the engine
+	// infers it from the TemplatePath extension (.md, .html, .txt).
+	Renderer        string
+	RendererOptions RendererOptions
+	DataSource      DataSourceConfig
+	Outputs         []string
+	Schedule        string
+	Delivery        []DeliveryConfig
+	Timeout         time.Duration
+	Labels          map[string]string
+	// Params are job parameters available to loaders, e.g. SQL query arguments.
+	Params map[string]string
+	// Retry retries failed runs and deliveries. Nil means a single attempt.
+	Retry *RetryPolicy
+	// Concurrency decides what happens when the job is due while a run of
+	// the same ID is still running. The default allows overlapping runs.
+	Concurrency ConcurrencyPolicy
 }
 
 // DataSourceConfig is generic; specific loaders will parse it.
 type DataSourceConfig map[string]string
 
 type DeliveryConfig map[string]string
+
+// RendererOptions are passed to renderers implementing ConfigurableRenderer.
+type RendererOptions map[string]string
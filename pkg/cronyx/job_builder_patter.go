@@ -8,8 +8,10 @@ import (
 
 // JobBuilder provides a fluent API for creating jobs
 type JobBuilder struct {
-	job ReportJob
-	err error
+	job    ReportJob
+	err    error
+	mode   CronMode
+	fields string // five-field spec set by the Schedule helpers
 }
 
 // NewJob creates a new job builder
@@ -24,6 +26,35 @@ func NewJob(name string) *JobBuilder {
 	}
 }
 
+// NewJob creates a job builder whose Schedule helpers emit expressions in
+// the engine's CronMode.
+func (e *Engine) NewJob(name string) *JobBuilder {
+	return NewJob(name).WithCronMode(e.cronMode)
+}
+
+// WithCronMode sets the cron mode the Schedule helpers write expressions
+// for (default CronWithSeconds). Use the mode of the engine the job is added to.
+func (jb *JobBuilder) WithCronMode(mode CronMode) *JobBuilder {
+	if jb.err != nil {
+		return jb
+	}
+	jb.mode = mode
+	if jb.fields != "" {
+		jb.setFields(jb.fields)
+	}
+	return jb
+}
+
+// setFields sets the schedule from a five-field spec, adding the seconds
+// field when the cron mode requires it.
+func (jb *JobBuilder) setFields(fields string) {
+	jb.fields = fields
+	jb.job.Schedule = fields
+	if jb.mode == CronWithSeconds {
+		jb.job.Schedule = "0 " + fields
+	}
+}
+
 // WithID sets a custom job ID
 func (jb *JobBuilder) WithID(id string) *JobBuilder {
 	if jb.err != nil {
@@ -42,6 +73,27 @@ func (jb *JobBuilder) WithTemplate(path string) *JobBuilder {
 	return jb
 }
 
+// WithRenderer selects a registered renderer by name
+func (jb *JobBuilder) WithRenderer(name string) *JobBuilder {
+	if jb.err != nil {
+		return jb
+	}
+	jb.job.Renderer = name
+	return jb
+}
+
+// WithRendererOption sets an option passed to the renderer
+func (jb *JobBuilder) WithRendererOption(key, value string) *JobBuilder {
+	if jb.err != nil {
+		return jb
+	}
+	if jb.job.RendererOptions == nil {
+		jb.job.RendererOptions = make(RendererOptions)
+	}
+	jb.job.RendererOptions[key] = value
+	return jb
+}
+
 // WithCSVData configures CSV data source
 func (jb *JobBuilder) WithCSVData(path string) *JobBuilder {
 	if jb.err != nil {
@@ -82,6 +134,30 @@ func (jb *JobBuilder) WithDatabaseData(dsn, query string) *JobBuilder {
 	return jb
 }
 
+// WithParam sets a job parameter
+func (jb *JobBuilder) WithParam(key, value string) *JobBuilder {
+	if jb.err != nil {
+		return jb
+	}
+	if jb.job.Params == nil {
+		jb.job.Params = make(map[string]string)
+	}
+	jb.job.Params[key] = value
+	return jb
+}
+
+// WithDataSourceOption sets a single data source option, e.g. "driver"
+func (jb *JobBuilder) WithDataSourceOption(key, value string) *JobBuilder {
+	if jb.err != nil {
+		return jb
+	}
+	if jb.job.DataSource == nil {
+		jb.job.DataSource = make(DataSourceConfig)
+	}
+	jb.job.DataSource[key] = value
+	return jb
+}
+
 // WithCustomDataSource allows any data source configuration
 func (jb *JobBuilder) WithCustomDataSource(config DataSourceConfig) *JobBuilder {
 	if jb.err != nil {
@@ -202,7 +278,7 @@ func (jb *JobBuilder) ScheduleDaily(hour, minute int) *JobBuilder {
 		jb.err = fmt.Errorf("invalid time: %d:%d", hour, minute)
 		return jb
 	}
-	jb.job.Schedule = fmt.Sprintf("0 %d %d * * *", minute, hour)
+	jb.setFields(fmt.Sprintf("%d %d * * *", minute, hour))
 	return jb
 }
 
@@ -215,7 +291,7 @@ func (jb *JobBuilder) ScheduleWeekly(weekday time.Weekday, hour, minute int) *Jo
 		jb.err = fmt.Errorf("invalid time: %d:%d", hour, minute)
 		return jb
 	}
-	jb.job.Schedule = fmt.Sprintf("0 %d %d * * %d", minute, hour, weekday)
+	jb.setFields(fmt.Sprintf("%d %d * * %d", minute, hour, weekday))
 	return jb
 }
 
@@ -228,7 +304,7 @@ func (jb *JobBuilder) ScheduleMonthly(day, hour, minute int) *JobBuilder {
 		jb.err = fmt.Errorf("invalid date/time: day %d, %d:%d", day, hour, minute)
 		return jb
 	}
-	jb.job.Schedule = fmt.Sprintf("0 %d %d %d * *", minute, hour, day)
+	jb.setFields(fmt.Sprintf("%d %d %d * *", minute, hour, day))
 	return jb
 }
 
@@ -241,6 +317,7 @@ func (jb *JobBuilder) ScheduleEvery(interval time.Duration) *JobBuilder {
 		jb.err = fmt.Errorf("interval too short: %v", interval)
 		return jb
 	}
+	jb.fields = ""
 	jb.job.Schedule = fmt.Sprintf("@every %s", interval.String())
 	return jb
 }
@@ -250,6 +327,7 @@ func (jb *JobBuilder) WithCronSchedule(cronExpr string) *JobBuilder {
 	if jb.err != nil {
 		return jb
 	}
+	jb.fields = ""
 	jb.job.Schedule = cronExpr
 	return jb
 }
@@ -263,6 +341,15 @@ func (jb *JobBuilder) WithTimeout(timeout time.Duration) *JobBuilder {
 	return jb
 }
 
+// WithRetry sets the retry policy for the job and its deliveries
+func (jb *JobBuilder) WithRetry(policy RetryPolicy) *JobBuilder {
+	if jb.err != nil {
+		return jb
+	}
+	jb.job.Retry = &policy
+	return jb
+}
+
 // WithLabel adds a label to the job
 func (jb *JobBuilder) WithLabel(key, value string) *JobBuilder {
 	if jb.err != nil {
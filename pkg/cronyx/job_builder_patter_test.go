@@ -0,0 +1,70 @@
+package cronyx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJobBuilderScheduleCronMode(t *testing.T) {
+	// a Monday, so that the weekly schedule fires later the same week
+	from := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	schedules := []struct {
+		name  string
+		set   func(*JobBuilder) *JobBuilder
+		want  string // five-field spec
+		first time.Time
+	}{
+		{"daily", func(jb *JobBuilder) *JobBuilder { return jb.ScheduleDaily(9, 30) },
+			"30 9 * * *", time.Date(2025, 1, 6, 9, 30, 0, 0, time.UTC)},
+		{"weekly", func(jb *JobBuilder) *JobBuilder { return jb.ScheduleWeekly(time.Friday, 17, 0) },
+			"0 17 * * 5", time.Date(2025, 1, 10, 17, 0, 0, 0, time.UTC)},
+		{"monthly", func(jb *JobBuilder) *JobBuilder { return jb.ScheduleMonthly(15, 6, 45) },
+			"45 6 15 * *", time.Date(2025, 1, 15, 6, 45, 0, 0, time.UTC)},
+	}
+	modes := []struct {
+		name    string
+		mode    CronMode
+		seconds bool
+	}{
+		{"with seconds", CronWithSeconds, true},
+		{"standard", CronStandard, false},
+		{"seconds optional", CronSecondsOptional, false},
+	}
+	for _, m := range modes {
+		for _, s := range schedules {
+			t.Run(m.name+"/"+s.name, func(t *testing.T) {
+				want := s.want
+				if m.seconds {
+					want = "0 " + want
+				}
+				e := NewEngineWithConfig(&Config{CronMode: m.mode})
+				builders := map[string]*JobBuilder{
+					"Engine.NewJob":      s.set(e.NewJob("report")),
+					"WithCronMode first": s.set(NewJob("report").WithCronMode(m.mode)),
+					"WithCronMode after": s.set(NewJob("report")).WithCronMode(m.mode),
+				}
+				for name, jb := range builders {
+					if got := jb.job.Schedule; got != want {
+						t.Errorf("%s: Schedule = %q, want %q", name, got, want)
+						continue
+					}
+					sched, err := e.cronParser.Parse(jb.job.Schedule)
+					if err != nil {
+						t.Errorf("%s: engine rejects %q: %v", name, jb.job.Schedule, err)
+						continue
+					}
+					if next := sched.Next(from); !next.Equal(s.first) {
+						t.Errorf("%s: first run %v, want %v", name, next, s.first)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestJobBuilderCustomScheduleIgnoresCronMode(t *testing.T) {
+	jb := NewJob("report").ScheduleDaily(9, 30).WithCronSchedule("*/5 * * * *").WithCronMode(CronWithSeconds)
+	if jb.job.Schedule != "*/5 * * * *" {
+		t.Errorf("Schedule = %q, want the custom expression unchanged", jb.job.Schedule)
+	}
+}
@@ -1,13 +1,46 @@
 package loaders
 
 import (
+	"bufio"
 	"context"
 	"encoding/csv"
-	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
 )
 
+// defaultDateLayouts are tried when inferring date columns.
+var defaultDateLayouts = []string{time.RFC3339, "2006-01-02", "2006-01-02 15:04:05"}
+
+// CSVLoader loads a CSV file. By default every cell is a string.
+//
+// Config keys:
+//
+//	path          file to read (required)
+//	delimiter     field delimiter, e.g. ";" or "tab" (default ",")
+//	comment       lines starting with this character are skipped
+//	header        "false" when the file has no header row
+//	columns       comma-separated column names; required without a header,
+//	              otherwise they replace the header names
+//	lazy_quotes   "true" to accept malformed quotes
+//	infer_types   "true" to convert columns to int64, float64, bool or
+//	              time.Time when every non-empty value parses
+//	empty_as_nil  "true" turns blank cells of typed or inferred columns
+//	              into nil instead of keeping them as strings; templates
+//	              print nil as "<no value>", so test for it with {{if}}
+//	type.<col>    explicit column type: string, int, float, bool, date or
+//	              date:<layout>
+//	date_layouts  layouts tried for date columns, separated by "|" since
+//	              layouts may contain commas, e.g. "Jan 2, 2006|2006-01-02"
+//	              (default RFC3339, 2006-01-02 and 2006-01-02 15:04:05)
+//
+// A leading UTF-8 byte order mark is always stripped.
 type CSVLoader struct{}
 
 func (c CSVLoader) Load(ctx context.Context, cfg cronyx.DataSourceConfig) (cronyx.DataPayload, error) {
@@ -18,13 +51,29 @@ func (c CSVLoader) Load(ctx context.Context, cfg cronyx.DataSourceConfig) (crony
 	}
 	defer f.Close()
 
-	r := csv.NewReader(f)
-	headers, err := r.Read()
+	r, err := newCSVReader(f, cfg)
 	if err != nil {
 		return cronyx.DataPayload{}, err
 	}
-	var rows []map[string]interface{}
+
+	headers := splitList(cfg["columns"])
+	if cfg["header"] != "false" {
+		first, err := r.Read()
+		if err != nil {
+			return cronyx.DataPayload{}, err
+		}
+		if len(headers) == 0 {
+			headers = first
+		}
+	} else if len(headers) == 0 {
+		return cronyx.DataPayload{}, fmt.Errorf("csv loader: columns are required when header is false")
+	}
+
+	var records [][]string
 	for {
+		if err := ctx.Err(); err != nil {
+			return cronyx.DataPayload{}, err
+		}
 		record, err := r.Read()
 		if err == io.EOF {
 			break
@@ -32,11 +81,195 @@ func (c CSVLoader) Load(ctx context.Context, cfg cronyx.DataSourceConfig) (crony
 		if err != nil {
 			return cronyx.DataPayload{}, err
 		}
+		records = append(records, record)
+	}
+
+	converters, err := columnConverters(headers, records, cfg)
+	if err != nil {
+		return cronyx.DataPayload{}, err
+	}
+
+	rows := make([]map[string]interface{}, 0, len(records))
+	for n, record := range records {
 		row := map[string]interface{}{}
 		for i, h := range headers {
-			row[h] = record[i]
+			var cell string
+			if i < len(record) {
+				cell = record[i]
+			}
+			v, err := converters[i](cell)
+			if err != nil {
+				return cronyx.DataPayload{}, fmt.Errorf("csv loader: row %d column %q: %w", n+1, h, err)
+			}
+			row[h] = v
 		}
 		rows = append(rows, row)
 	}
-	return cronyx.DataPayload{Rows: rows}, nil
+	return cronyx.DataPayload{Rows: rows, Columns: headers}, nil
+}
+
+func newCSVReader(f io.Reader, cfg cronyx.DataSourceConfig) (*csv.Reader, error) {
+	br := bufio.NewReader(f)
+	if bom, err := br.Peek(3); err == nil && string(bom) == "\xef\xbb\xbf" {
+		br.Discard(3)
+	}
+
+	r := csv.NewReader(br)
+	r.LazyQuotes = cfg["lazy_quotes"] == "true"
+
+	switch d := cfg["delimiter"]; d {
+	case "":
+	case "tab", `\t`:
+		r.Comma = '\t'
+	default:
+		ch, size := utf8.DecodeRuneInString(d)
+		if size != len(d) {
+			return nil, fmt.Errorf("csv loader: delimiter must be a single character, got %q", d)
+		}
+		r.Comma = ch
+	}
+	if cm := cfg["comment"]; cm != "" {
+		ch, size := utf8.DecodeRuneInString(cm)
+		if size != len(cm) {
+			return nil, fmt.Errorf("csv loader: comment must be a single character, got %q", cm)
+		}
+		r.Comment = ch
+	}
+	return r, nil
+}
+
+type cellConverter func(string) (interface{}, error)
+
+func asString(s string) (interface{}, error) { return s, nil }
+
+// columnConverters picks a converter per column from the explicit schema,
+// falling back to inference when enabled.
+func columnConverters(headers []string, records [][]string, cfg cronyx.DataSourceConfig) ([]cellConverter, error) {
+	var layouts []string
+	for _, layout := range strings.Split(cfg["date_layouts"], "|") {
+		if layout = strings.TrimSpace(layout); layout != "" {
+			layouts = append(layouts, layout)
+		}
+	}
+	if len(layouts) == 0 {
+		layouts = defaultDateLayouts
+	}
+	infer := cfg["infer_types"] == "true"
+	blank := keepBlank
+	if cfg["empty_as_nil"] == "true" {
+		blank = nilIfBlank
+	}
+
+	converters := make([]cellConverter, len(headers))
+	for i, h := range headers {
+		if typ, ok := cfg["type."+h]; ok {
+			conv, err := typedConverter(typ, layouts, blank)
+			if err != nil {
+				return nil, fmt.Errorf("csv loader: column %q: %w", h, err)
+			}
+			converters[i] = conv
+			continue
+		}
+		if !infer {
+			converters[i] = asString
+			continue
+		}
+		converters[i] = inferConverter(i, records, layouts, blank)
+	}
+	return converters, nil
+}
+
+// typedConverter returns the converter for an explicit column type.
+func typedConverter(typ string, layouts []string, blank blankHandler) (cellConverter, error) {
+	name, layout, _ := strings.Cut(typ, ":")
+	if layout != "" {
+		layouts = []string{layout}
+	}
+	var conv cellConverter
+	switch name {
+	case "string":
+		return asString, nil
+	case "int":
+		conv = func(s string) (interface{}, error) { return strconv.ParseInt(strings.TrimSpace(s), 10, 64) }
+	case "float":
+		conv = func(s string) (interface{}, error) { return strconv.ParseFloat(strings.TrimSpace(s), 64) }
+	case "bool":
+		conv = parseBool
+	case "date", "time":
+		conv = func(s string) (interface{}, error) { return parseDate(strings.TrimSpace(s), layouts) }
+	default:
+		return nil, fmt.Errorf("unknown type %q", typ)
+	}
+	return blank(conv), nil
+}
+
+// inferConverter picks the narrowest type that every non-empty value of the
+// column parses as: int, float, bool, date, otherwise string.
+func inferConverter(col int, records [][]string, layouts []string, blank blankHandler) cellConverter {
+	candidates := []cellConverter{
+		func(s string) (interface{}, error) { return strconv.ParseInt(strings.TrimSpace(s), 10, 64) },
+		func(s string) (interface{}, error) { return strconv.ParseFloat(strings.TrimSpace(s), 64) },
+		parseBool,
+		func(s string) (interface{}, error) { return parseDate(strings.TrimSpace(s), layouts) },
+	}
+
+	for _, conv := range candidates {
+		ok, seen := true, false
+		for _, record := range records {
+			if col >= len(record) || strings.TrimSpace(record[col]) == "" {
+				continue
+			}
+			seen = true
+			if _, err := conv(record[col]); err != nil {
+				ok = false
+				break
+			}
+		}
+		if ok && seen {
+			return blank(conv)
+		}
+	}
+	return blank(asString)
+}
+
+// blankHandler wraps a converter to decide what blank cells become.
+type blankHandler func(cellConverter) cellConverter
+
+// keepBlank wraps conv so that blank cells stay strings.
+func keepBlank(conv cellConverter) cellConverter {
+	return func(s string) (interface{}, error) {
+		if strings.TrimSpace(s) == "" {
+			return s, nil
+		}
+		return conv(s)
+	}
+}
+
+// nilIfBlank wraps conv so that blank cells become nil.
+func nilIfBlank(conv cellConverter) cellConverter {
+	return func(s string) (interface{}, error) {
+		if strings.TrimSpace(s) == "" {
+			return nil, nil
+		}
+		return conv(s)
+	}
+}
+
+func parseBool(s string) (interface{}, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return nil, fmt.Errorf("invalid bool %q", s)
+}
+
+func parseDate(s string, layouts []string) (interface{}, error) {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid date %q", s)
 }
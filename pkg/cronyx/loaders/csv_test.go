@@ -0,0 +1,162 @@
+package loaders
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+// loadCSV writes content to a file and loads it with cfg.
+func loadCSV(t *testing.T, content string, cfg cronyx.DataSourceConfig) (cronyx.DataPayload, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	full := cronyx.DataSourceConfig{"path": path}
+	for k, v := range cfg {
+		full[k] = v
+	}
+	return CSVLoader{}.Load(context.Background(), full)
+}
+
+func day(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+func TestCSVLoaderParsing(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		cfg     cronyx.DataSourceConfig
+		columns []string
+		rows    []map[string]interface{}
+	}{
+		{
+			name:    "strings by default",
+			content: "\xef\xbb\xbfid,name\n1,north\n2,\n",
+			columns: []string{"id", "name"},
+			rows:    []map[string]interface{}{{"id": "1", "name": "north"}, {"id": "2", "name": ""}},
+		},
+		{
+			name:    "no header",
+			content: "1,north\n2,south\n",
+			cfg:     cronyx.DataSourceConfig{"header": "false", "columns": "id, name"},
+			columns: []string{"id", "name"},
+			rows:    []map[string]interface{}{{"id": "1", "name": "north"}, {"id": "2", "name": "south"}},
+		},
+		{
+			name:    "columns rename the header",
+			content: "a;b\n# skipped\n1;x \"y\" z\n",
+			cfg:     cronyx.DataSourceConfig{"columns": "id,note", "delimiter": ";", "comment": "#", "lazy_quotes": "true"},
+			columns: []string{"id", "note"},
+			rows:    []map[string]interface{}{{"id": "1", "note": `x "y" z`}},
+		},
+		{
+			name:    "tab delimited",
+			content: "id\tname\n1\ta,b\n",
+			cfg:     cronyx.DataSourceConfig{"delimiter": "tab"},
+			columns: []string{"id", "name"},
+			rows:    []map[string]interface{}{{"id": "1", "name": "a,b"}},
+		},
+		{
+			name:    "inferred types",
+			content: "n,f,b,d,s,e\n1,1.5,true,2025-03-01,x,\n 2 ,2,FALSE,2025-03-02,3,\n,,,,,\n",
+			cfg:     cronyx.DataSourceConfig{"infer_types": "true"},
+			columns: []string{"n", "f", "b", "d", "s", "e"},
+			rows: []map[string]interface{}{
+				{"n": int64(1), "f": 1.5, "b": true, "d": day(2025, 3, 1), "s": "x", "e": ""},
+				{"n": int64(2), "f": 2.0, "b": false, "d": day(2025, 3, 2), "s": "3", "e": ""},
+				{"n": "", "f": "", "b": "", "d": "", "s": "", "e": ""},
+			},
+		},
+		{
+			name:    "empty as nil",
+			content: "n,s\n1,x\n,\n",
+			cfg:     cronyx.DataSourceConfig{"infer_types": "true", "empty_as_nil": "true"},
+			columns: []string{"n", "s"},
+			rows:    []map[string]interface{}{{"n": int64(1), "s": "x"}, {"n": nil, "s": nil}},
+		},
+		{
+			name:    "explicit types",
+			content: "zip,qty,shipped,at\n01234,3,01/02/2025,2025-03-01T10:00:00Z\n",
+			cfg: cronyx.DataSourceConfig{
+				"infer_types":  "true",
+				"type.zip":     "string",
+				"type.qty":     "float",
+				"type.shipped": "date:02/01/2006",
+				"type.at":      "time",
+			},
+			columns: []string{"zip", "qty", "shipped", "at"},
+			rows: []map[string]interface{}{
+				{"zip": "01234", "qty": 3.0, "shipped": day(2025, 2, 1), "at": time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:    "date layouts with commas",
+			content: "day,due\n\"Mar 1, 2025\",\"Mar 5, 2025\"\n\"Apr 2, 2025\",2025-04-09\n",
+			cfg:     cronyx.DataSourceConfig{"infer_types": "true", "date_layouts": "Jan 2, 2006 | 2006-01-02", "type.due": "date"},
+			columns: []string{"day", "due"},
+			rows: []map[string]interface{}{
+				{"day": day(2025, 3, 1), "due": day(2025, 3, 5)},
+				{"day": day(2025, 4, 2), "due": day(2025, 4, 9)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := loadCSV(t, tt.content, tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(p.Columns, tt.columns) {
+				t.Errorf("Columns = %q, want %q", p.Columns, tt.columns)
+			}
+			if !reflect.DeepEqual(p.Rows, tt.rows) {
+				t.Errorf("Rows =\n%#v\nwant\n%#v", p.Rows, tt.rows)
+			}
+		})
+	}
+}
+
+func TestCSVLoaderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		cfg     cronyx.DataSourceConfig
+		wantErr string
+	}{
+		{"delimiter", "a\n", cronyx.DataSourceConfig{"delimiter": ";;"}, "delimiter must be a single character"},
+		{"comment", "a\n", cronyx.DataSourceConfig{"comment": "//"}, "comment must be a single character"},
+		{"no columns", "1\n", cronyx.DataSourceConfig{"header": "false"}, "columns are required"},
+		{"unknown type", "a\n1\n", cronyx.DataSourceConfig{"type.a": "money"}, `column "a": unknown type "money"`},
+		{"bad cell", "a\n1\nx\n", cronyx.DataSourceConfig{"type.a": "int"}, `row 2 column "a"`},
+		{"bad date", "a\n2025-03-01\n", cronyx.DataSourceConfig{"type.a": "date", "date_layouts": "02/01/2006"}, `invalid date "2025-03-01"`},
+		{"quotes", "a\n\"x\"y\n", nil, "extraneous"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadCSV(t, tt.content, tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := (CSVLoader{}).Load(context.Background(), cronyx.DataSourceConfig{"path": filepath.Join(t.TempDir(), "missing.csv")}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing file: err = %v, want os.ErrNotExist", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "data.csv")
+	os.WriteFile(path, []byte("a\n1\n"), 0o644)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := (CSVLoader{}).Load(ctx, cronyx.DataSourceConfig{"path": path}); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: err = %v, want context.Canceled", err)
+	}
+}
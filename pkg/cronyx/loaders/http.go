@@ -0,0 +1,277 @@
+package loaders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+// DefaultMaxPages bounds pagination when max_pages is not configured.
+const DefaultMaxPages = 100
+
+// HTTPLoader fetches JSON rows from a REST endpoint.
+//
+// Config keys:
+//
+//	url              endpoint (required)
+//	method           HTTP method (default GET)
+//	header.<Name>    request header
+//	query.<name>     query string parameter
+//	body             JSON request body
+//	bearer_token     sets "Authorization: Bearer <token>"
+//	basic_user       basic auth user (with basic_password)
+//	root             path to the row array, e.g. "data.items"
+//	separator        joins nested keys when flattening (default ".")
+//	flatten          "false" keeps nested objects as maps
+//	paginate         "link", "cursor" or "page"
+//	cursor_path      response path of the next cursor (cursor pagination)
+//	cursor_param     query parameter carrying the cursor (default "cursor")
+//	page_param       query parameter carrying the page (default "page")
+//	page_start       first page number (default 1)
+//	page_size_param  query parameter carrying the page size
+//	page_size        page size value
+//	max_pages        stop after this many requests (default DefaultMaxPages)
+//	on_max_pages     "error" (default) fails the load when more pages remain
+//	                 after max_pages, "truncate" returns the rows fetched
+//
+// Rows from every page are combined. Page pagination stops at the first
+// empty page, cursor pagination when the cursor is missing or empty, and
+// link pagination when there is no rel="next" Link header.
+//
+// Headers, bearer_token and basic auth are only sent to the scheme and host
+// of url. A next link pointing elsewhere is followed without them. Errors
+// name URLs without their query string, which may carry API keys.
+type HTTPLoader struct {
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+	// Logger reports pagination cut short by max_pages (default log.Default()).
+	Logger cronyx.Logger
+}
+
+func (l HTTPLoader) Load(ctx context.Context, cfg cronyx.DataSourceConfig) (cronyx.DataPayload, error) {
+	if cfg["url"] == "" {
+		return cronyx.DataPayload{}, fmt.Errorf("http loader: url is required")
+	}
+	next, err := url.Parse(cfg["url"])
+	if err != nil {
+		return cronyx.DataPayload{}, fmt.Errorf("http loader: invalid url: %w", err)
+	}
+	q := next.Query()
+	for k, v := range cfg {
+		if name, ok := strings.CutPrefix(k, "query."); ok {
+			q.Set(name, v)
+		}
+	}
+
+	maxPages := DefaultMaxPages
+	if v := cfg["max_pages"]; v != "" {
+		if maxPages, err = strconv.Atoi(v); err != nil || maxPages < 1 {
+			return cronyx.DataPayload{}, fmt.Errorf("http loader: invalid max_pages %q", v)
+		}
+	}
+	truncate := false
+	switch v := cfg["on_max_pages"]; v {
+	case "", "error":
+	case "truncate":
+		truncate = true
+	default:
+		return cronyx.DataPayload{}, fmt.Errorf("http loader: invalid on_max_pages %q", v)
+	}
+
+	mode := cfg["paginate"]
+	page := 1
+	if v := cfg["page_start"]; v != "" {
+		if page, err = strconv.Atoi(v); err != nil {
+			return cronyx.DataPayload{}, fmt.Errorf("http loader: invalid page_start %q", v)
+		}
+	}
+	switch mode {
+	case "", "link", "cursor":
+	case "page":
+		q.Set(valueOr(cfg["page_param"], "page"), strconv.Itoa(page))
+		if cfg["page_size_param"] != "" {
+			q.Set(cfg["page_size_param"], cfg["page_size"])
+		}
+	default:
+		return cronyx.DataPayload{}, fmt.Errorf("http loader: unknown paginate mode %q", mode)
+	}
+	next.RawQuery = q.Encode()
+	origin := *next
+
+	var rows []map[string]interface{}
+	var raw []byte
+	for n := 0; next != nil && n < maxPages; n++ {
+		creds := next.Scheme == origin.Scheme && next.Host == origin.Host
+		body, header, err := l.fetch(ctx, next, cfg, creds)
+		if err != nil {
+			return cronyx.DataPayload{}, err
+		}
+		raw = body
+
+		var doc interface{}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return cronyx.DataPayload{}, fmt.Errorf("http loader: invalid JSON from %s: %w", redact(next), err)
+		}
+		v, err := lookupPath(doc, cfg["root"])
+		if err != nil {
+			return cronyx.DataPayload{}, fmt.Errorf("http loader: %w", err)
+		}
+		pageRows := toRows(v, valueOr(cfg["separator"], "."), cfg["flatten"] != "false")
+		rows = append(rows, pageRows...)
+
+		cur := next
+		next = nil
+		switch mode {
+		case "link":
+			if link := nextLink(header); link != "" {
+				if next, err = cur.Parse(link); err != nil {
+					return cronyx.DataPayload{}, fmt.Errorf("http loader: invalid Link header: %w", err)
+				}
+			}
+		case "cursor":
+			c, err := lookupPath(doc, cfg["cursor_path"])
+			if err != nil || c == nil || fmt.Sprint(normalize(c)) == "" {
+				break
+			}
+			u := *cur
+			uq := u.Query()
+			uq.Set(valueOr(cfg["cursor_param"], "cursor"), fmt.Sprint(normalize(c)))
+			u.RawQuery = uq.Encode()
+			next = &u
+		case "page":
+			if len(pageRows) == 0 {
+				break
+			}
+			page++
+			u := *cur
+			uq := u.Query()
+			uq.Set(valueOr(cfg["page_param"], "page"), strconv.Itoa(page))
+			u.RawQuery = uq.Encode()
+			next = &u
+		}
+	}
+
+	if next != nil {
+		if !truncate {
+			return cronyx.DataPayload{}, fmt.Errorf("http loader: more than %d pages from %s; raise max_pages or set on_max_pages to truncate", maxPages, redact(&origin))
+		}
+		logger := l.Logger
+		if logger == nil {
+			logger = log.Default()
+		}
+		logger.Printf("http loader: stopped after %d pages from %s, keeping %d rows", maxPages, redact(&origin), len(rows))
+	}
+
+	p := cronyx.DataPayload{Rows: rows}
+	if mode == "" {
+		p.Raw = raw
+	}
+	return p, nil
+}
+
+// fetch performs one request and returns the body of a 2xx response. The
+// configured headers and credentials are only set when creds is true.
+func (l HTTPLoader) fetch(ctx context.Context, u *url.URL, cfg cronyx.DataSourceConfig, creds bool) ([]byte, http.Header, error) {
+	method := valueOr(cfg["method"], http.MethodGet)
+	var body io.Reader
+	if cfg["body"] != "" {
+		body = strings.NewReader(cfg["body"])
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("http loader: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if creds {
+		for k, v := range cfg {
+			if name, ok := strings.CutPrefix(k, "header."); ok {
+				req.Header.Set(name, v)
+			}
+		}
+		switch {
+		case cfg["bearer_token"] != "":
+			req.Header.Set("Authorization", "Bearer "+cfg["bearer_token"])
+		case cfg["basic_user"] != "":
+			req.SetBasicAuth(cfg["basic_user"], cfg["basic_password"])
+		}
+	}
+
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			uerr.URL = redact(u)
+		}
+		return nil, nil, fmt.Errorf("http loader: %w", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("http loader: failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet := string(b)
+		if len(snippet) > 200 {
+			snippet = snippet[:200] + "..."
+		}
+		return nil, nil, fmt.Errorf("http loader: %s %s: %s: %s", method, redact(u), resp.Status, snippet)
+	}
+	return b, resp.Header, nil
+}
+
+// nextLink extracts the rel="next" target from Link headers.
+func nextLink(h http.Header) string {
+	for _, v := range h.Values("Link") {
+		for _, link := range strings.Split(v, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, p := range parts[1:] {
+				p = strings.TrimSpace(p)
+				if p == `rel="next"` || p == "rel=next" {
+					return target[1 : len(target)-1]
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// redact formats u for error messages without its query string and with
+// any password masked.
+func redact(u *url.URL) string {
+	r := *u
+	r.RawQuery, r.ForceQuery = "", false
+	r.Fragment, r.RawFragment = "", ""
+	return r.Redacted()
+}
+
+func valueOr(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,357 @@
+package loaders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+// pagedItems serves ids 1..total in pages of size per page, with the
+// pagination style selected by the "style" query parameter.
+func pagedItems(t *testing.T, total, size int) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		page := 1
+		switch q.Get("style") {
+		case "cursor":
+			if c := q.Get("cursor"); c != "" {
+				page, _ = strconv.Atoi(strings.TrimPrefix(c, "c"))
+			}
+		case "page":
+			page, _ = strconv.Atoi(q.Get("page"))
+		case "link":
+			if p := q.Get("p"); p != "" {
+				page, _ = strconv.Atoi(p)
+			}
+		}
+
+		var items []map[string]interface{}
+		for id := (page-1)*size + 1; id <= page*size && id <= total; id++ {
+			items = append(items, map[string]interface{}{"id": id})
+		}
+		more := page*size < total
+		resp := map[string]interface{}{"data": map[string]interface{}{"items": items}}
+		switch q.Get("style") {
+		case "cursor":
+			next := ""
+			if more {
+				next = fmt.Sprintf("c%d", page+1)
+			}
+			resp["meta"] = map[string]interface{}{"next": next}
+		case "link":
+			if more {
+				w.Header().Set("Link", fmt.Sprintf(`<%s/items?style=link&p=%d>; rel="next", <%s/items?style=link&p=1>; rel="first"`, srv.URL, page+1, srv.URL))
+			}
+		}
+		if items == nil {
+			resp["data"] = map[string]interface{}{"items": []interface{}{}}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func rowIDs(t *testing.T, rows []map[string]interface{}) []int {
+	t.Helper()
+	var ids []int
+	for _, row := range rows {
+		n, err := strconv.Atoi(fmt.Sprint(row["id"]))
+		if err != nil {
+			t.Fatalf("row %v: %v", row, err)
+		}
+		ids = append(ids, n)
+	}
+	return ids
+}
+
+func seq(from, to int) []int {
+	var s []int
+	for i := from; i <= to; i++ {
+		s = append(s, i)
+	}
+	return s
+}
+
+func TestHTTPLoaderPagination(t *testing.T) {
+	srv := pagedItems(t, 7, 3)
+	tests := []struct {
+		name string
+		cfg  cronyx.DataSourceConfig
+		want []int
+	}{
+		{
+			name: "no pagination",
+			cfg:  cronyx.DataSourceConfig{"query.style": "none"},
+			want: seq(1, 3),
+		},
+		{
+			name: "link header",
+			cfg:  cronyx.DataSourceConfig{"query.style": "link", "paginate": "link"},
+			want: seq(1, 7),
+		},
+		{
+			name: "cursor",
+			cfg:  cronyx.DataSourceConfig{"query.style": "cursor", "paginate": "cursor", "cursor_path": "meta.next"},
+			want: seq(1, 7),
+		},
+		{
+			name: "page number",
+			cfg:  cronyx.DataSourceConfig{"query.style": "page", "paginate": "page"},
+			want: seq(1, 7),
+		},
+		{
+			name: "page number from page_start",
+			cfg:  cronyx.DataSourceConfig{"query.style": "page", "paginate": "page", "page_start": "2"},
+			want: seq(4, 7),
+		},
+		{
+			name: "max_pages fits the pages",
+			cfg:  cronyx.DataSourceConfig{"query.style": "link", "paginate": "link", "max_pages": "3"},
+			want: seq(1, 7),
+		},
+		{
+			name: "truncate keeps the rows fetched",
+			cfg:  cronyx.DataSourceConfig{"query.style": "page", "paginate": "page", "max_pages": "2", "on_max_pages": "truncate"},
+			want: seq(1, 6),
+		},
+		{
+			name: "truncate with cursor",
+			cfg:  cronyx.DataSourceConfig{"query.style": "cursor", "paginate": "cursor", "cursor_path": "meta.next", "max_pages": "1", "on_max_pages": "truncate"},
+			want: seq(1, 3),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := cronyx.DataSourceConfig{"url": srv.URL + "/items", "root": "data.items"}
+			for k, v := range tt.cfg {
+				cfg[k] = v
+			}
+			var logs logRecorder
+			p, err := HTTPLoader{Logger: &logs}.Load(context.Background(), cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rowIDs(t, p.Rows); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ids = %v, want %v", got, tt.want)
+			}
+			if truncated := cfg["on_max_pages"] != ""; truncated != (len(logs) == 1) {
+				t.Errorf("logged %q", logs)
+			}
+		})
+	}
+}
+
+type logRecorder []string
+
+func (l *logRecorder) Printf(format string, v ...interface{}) {
+	*l = append(*l, fmt.Sprintf(format, v...))
+}
+
+func TestHTTPLoaderMaxPages(t *testing.T) {
+	srv := pagedItems(t, 7, 3)
+	cfg := cronyx.DataSourceConfig{
+		"url":         srv.URL + "/items",
+		"root":        "data.items",
+		"query.style": "cursor",
+		"paginate":    "cursor",
+		"cursor_path": "meta.next",
+		"max_pages":   "2",
+	}
+	_, err := HTTPLoader{}.Load(context.Background(), cfg)
+	if err == nil || !strings.Contains(err.Error(), "more than 2 pages") {
+		t.Errorf("err = %v, want the max_pages error", err)
+	}
+}
+
+func TestHTTPLoaderCrossOriginLink(t *testing.T) {
+	var other *httptest.Server
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" || r.Header.Get("X-Tenant") != "acme" {
+			t.Errorf("origin request headers = %v", r.Header)
+		}
+		next := other.URL + "/more"
+		if r.URL.Path == "/" {
+			next = "/second"
+		}
+		if r.URL.Path != "/third" {
+			w.Header().Set("Link", "<"+next+`>; rel="next"`)
+		}
+		io.WriteString(w, `[{"id": 1}]`)
+	}))
+	defer srv.Close()
+	other = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" || r.Header.Get("X-Tenant") != "" {
+			t.Errorf("credentials sent to another host: %v", r.Header)
+		}
+		// links back to the origin carry the credentials again
+		w.Header().Set("Link", "<"+srv.URL+`/third>; rel="next"`)
+		io.WriteString(w, `[{"id": 2}]`)
+	}))
+	defer other.Close()
+
+	p, err := HTTPLoader{}.Load(context.Background(), cronyx.DataSourceConfig{
+		"url":             srv.URL + "/",
+		"paginate":        "link",
+		"bearer_token":    "s3cret",
+		"header.X-Tenant": "acme",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rowIDs(t, p.Rows); !reflect.DeepEqual(got, []int{1, 1, 2, 1}) {
+		t.Errorf("ids = %v", got)
+	}
+}
+
+func TestHTTPLoaderRequest(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   cronyx.DataSourceConfig
+		check func(t *testing.T, r *http.Request, body string)
+	}{
+		{
+			name: "bearer token",
+			cfg:  cronyx.DataSourceConfig{"bearer_token": "s3cret"},
+			check: func(t *testing.T, r *http.Request, _ string) {
+				if got := r.Header.Get("Authorization"); got != "Bearer s3cret" {
+					t.Errorf("Authorization = %q", got)
+				}
+			},
+		},
+		{
+			name: "basic auth",
+			cfg:  cronyx.DataSourceConfig{"basic_user": "ann", "basic_password": "pw"},
+			check: func(t *testing.T, r *http.Request, _ string) {
+				if u, p, ok := r.BasicAuth(); !ok || u != "ann" || p != "pw" {
+					t.Errorf("BasicAuth = %q, %q, %v", u, p, ok)
+				}
+			},
+		},
+		{
+			name: "headers and query",
+			cfg:  cronyx.DataSourceConfig{"header.X-Tenant": "acme", "query.status": "open"},
+			check: func(t *testing.T, r *http.Request, _ string) {
+				if got := r.Header.Get("X-Tenant"); got != "acme" {
+					t.Errorf("X-Tenant = %q", got)
+				}
+				if got := r.URL.Query().Get("status"); got != "open" {
+					t.Errorf("status = %q", got)
+				}
+				if got := r.URL.Query().Get("keep"); got != "1" {
+					t.Errorf("query from url lost: keep = %q", got)
+				}
+			},
+		},
+		{
+			name: "json body",
+			cfg:  cronyx.DataSourceConfig{"method": "POST", "body": `{"q":"x"}`},
+			check: func(t *testing.T, r *http.Request, body string) {
+				if r.Method != http.MethodPost {
+					t.Errorf("Method = %s", r.Method)
+				}
+				if got := r.Header.Get("Content-Type"); got != "application/json" {
+					t.Errorf("Content-Type = %q", got)
+				}
+				if body != `{"q":"x"}` {
+					t.Errorf("body = %q", body)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				tt.check(t, r, string(body))
+				io.WriteString(w, `[{"id": 1}]`)
+			}))
+			defer srv.Close()
+
+			cfg := cronyx.DataSourceConfig{"url": srv.URL + "/?keep=1"}
+			for k, v := range tt.cfg {
+				cfg[k] = v
+			}
+			p, err := HTTPLoader{}.Load(context.Background(), cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rowIDs(t, p.Rows); !reflect.DeepEqual(got, []int{1}) {
+				t.Errorf("ids = %v", got)
+			}
+		})
+	}
+}
+
+func TestHTTPLoaderErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/denied":
+			http.Error(w, "no access", http.StatusForbidden)
+		case "/garbage":
+			io.WriteString(w, "<html>")
+		}
+	}))
+	defer srv.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name    string
+		cfg     cronyx.DataSourceConfig
+		wantErr string
+	}{
+		{"missing url", cronyx.DataSourceConfig{}, "url is required"},
+		{"status", cronyx.DataSourceConfig{"url": srv.URL + "/denied"}, "403 Forbidden: no access"},
+		{"invalid json", cronyx.DataSourceConfig{"url": srv.URL + "/garbage"}, "invalid JSON"},
+		{"unreachable", cronyx.DataSourceConfig{"url": closed.URL + "/items"}, closed.URL + "/items"},
+		{"paginate mode", cronyx.DataSourceConfig{"url": srv.URL, "paginate": "offset"}, "unknown paginate mode"},
+		{"max_pages", cronyx.DataSourceConfig{"url": srv.URL, "max_pages": "0"}, "invalid max_pages"},
+		{"on_max_pages", cronyx.DataSourceConfig{"url": srv.URL, "on_max_pages": "skip"}, "invalid on_max_pages"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg["query.api_key"] = "k3y"
+			_, err := HTTPLoader{}.Load(context.Background(), tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+			if err != nil && strings.Contains(err.Error(), "k3y") {
+				t.Errorf("err = %v, leaks the query string", err)
+			}
+		})
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	tests := []struct {
+		links []string
+		want  string
+	}{
+		{[]string{`<https://x/2>; rel="next"`}, "https://x/2"},
+		{[]string{`<https://x/1>; rel="prev", <https://x/3>; rel=next`}, "https://x/3"},
+		{[]string{`<https://x/1>; rel="first"`, `<https://x/4>; rel="next"`}, "https://x/4"},
+		{[]string{`<https://x/1>; rel="last"`}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		for _, l := range tt.links {
+			h.Add("Link", l)
+		}
+		if got := nextLink(h); got != tt.want {
+			t.Errorf("nextLink(%q) = %q, want %q", tt.links, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package loaders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+// JSONLoader loads a JSON file holding an array of objects, a single object,
+// or newline-delimited objects (NDJSON).
+//
+// Config keys:
+//
+//	path       file to read (required)
+//	format     "json", "ndjson" or empty to detect
+//	root       path to the row array, e.g. "data.items" or "$.results[0].rows"
+//	separator  joins nested object keys when flattening (default ".")
+//	flatten    "false" keeps nested objects as maps
+//	sets       extra named data sets as name=path pairs, e.g.
+//	           "orders=data.orders,customers=data.customers"
+type JSONLoader struct{}
+
+func (JSONLoader) Load(ctx context.Context, cfg cronyx.DataSourceConfig) (cronyx.DataPayload, error) {
+	path := cfg["path"]
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return cronyx.DataPayload{}, err
+	}
+
+	rows, err := decodeJSONRows(ctx, raw, cfg)
+	if err != nil {
+		return cronyx.DataPayload{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	payload := cronyx.DataPayload{Rows: rows, Raw: raw}
+
+	for _, set := range splitList(cfg["sets"]) {
+		name, root, ok := strings.Cut(set, "=")
+		if !ok {
+			return cronyx.DataPayload{}, fmt.Errorf("invalid data set %q, want name=path", set)
+		}
+		setCfg := cronyx.DataSourceConfig{}
+		for k, v := range cfg {
+			setCfg[k] = v
+		}
+		setCfg["root"] = strings.TrimSpace(root)
+		setRows, err := decodeJSONRows(ctx, raw, setCfg)
+		if err != nil {
+			return cronyx.DataPayload{}, fmt.Errorf("failed to parse %s set %q: %w", path, name, err)
+		}
+		payload.Sets = append(payload.Sets, cronyx.DataSet{Name: strings.TrimSpace(name), Rows: setRows})
+	}
+	return payload, nil
+}
+
+// decodeJSONRows decodes raw JSON or NDJSON into rows following the
+// format, root, separator and flatten options of cfg.
+func decodeJSONRows(ctx context.Context, raw []byte, cfg cronyx.DataSourceConfig) ([]map[string]interface{}, error) {
+	sep := cfg["separator"]
+	if sep == "" {
+		sep = "."
+	}
+	flat := cfg["flatten"] != "false"
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var values []interface{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var v interface{}
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+
+	format := cfg["format"]
+	if format == "" {
+		format = "json"
+		if len(values) > 1 {
+			format = "ndjson"
+		}
+	}
+
+	switch format {
+	case "json":
+		if len(values) != 1 {
+			return nil, fmt.Errorf("expected a single JSON document, found %d", len(values))
+		}
+		v, err := lookupPath(values[0], cfg["root"])
+		if err != nil {
+			return nil, err
+		}
+		return toRows(v, sep, flat), nil
+	case "ndjson":
+		rows := make([]map[string]interface{}, 0, len(values))
+		for _, value := range values {
+			v, err := lookupPath(value, cfg["root"])
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, toRows(v, sep, flat)...)
+		}
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
@@ -0,0 +1,145 @@
+package loaders
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// lookupPath walks a decoded JSON value along a dotted path such as
+// "data.items" or "$.results[0].rows". An empty path (or "$") returns v.
+func lookupPath(v interface{}, path string) (interface{}, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return v, nil
+	}
+
+	cur := v
+	for _, part := range strings.Split(path, ".") {
+		name, indexes, err := splitIndexes(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q: %w", path, err)
+		}
+		if name != "" {
+			obj, ok := cur.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("path %q: %q is not an object", path, name)
+			}
+			if cur, ok = obj[name]; !ok {
+				return nil, fmt.Errorf("path %q: key %q not found", path, name)
+			}
+		}
+		for _, i := range indexes {
+			arr, ok := cur.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("path %q: %q is not an array", path, part)
+			}
+			if i < 0 || i >= len(arr) {
+				return nil, fmt.Errorf("path %q: index %d out of range", path, i)
+			}
+			cur = arr[i]
+		}
+	}
+	return cur, nil
+}
+
+// splitIndexes splits "items[0][1]" into "items" and [0 1].
+func splitIndexes(part string) (string, []int, error) {
+	open := strings.IndexByte(part, '[')
+	if open < 0 {
+		return part, nil, nil
+	}
+	name, rest := part[:open], part[open:]
+	var indexes []int
+	for rest != "" {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end < 0 {
+			return "", nil, fmt.Errorf("malformed index in %q", part)
+		}
+		i, err := strconv.Atoi(rest[1:end])
+		if err != nil {
+			return "", nil, fmt.Errorf("malformed index in %q", part)
+		}
+		indexes = append(indexes, i)
+		rest = rest[end+1:]
+	}
+	return name, indexes, nil
+}
+
+// toRows turns a decoded JSON value into rows: an array yields one row per
+// element, an object yields a single row. Scalars are wrapped as {"value": v}.
+func toRows(v interface{}, sep string, flat bool) []map[string]interface{} {
+	var items []interface{}
+	switch t := v.(type) {
+	case []interface{}:
+		items = t
+	case nil:
+		return nil
+	default:
+		items = []interface{}{t}
+	}
+
+	rows := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		rows = append(rows, toRow(item, sep, flat))
+	}
+	return rows
+}
+
+func toRow(item interface{}, sep string, flat bool) map[string]interface{} {
+	obj, ok := item.(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{"value": normalize(item)}
+	}
+	row := map[string]interface{}{}
+	if flat {
+		flatten("", obj, sep, row)
+		return row
+	}
+	for k, v := range obj {
+		row[k] = normalize(v)
+	}
+	return row
+}
+
+// flatten copies obj into out, joining nested object keys with sep.
+// Arrays are kept as values.
+func flatten(prefix string, obj map[string]interface{}, sep string, out map[string]interface{}) {
+	for k, v := range obj {
+		key := k
+		if prefix != "" {
+			key = prefix + sep + k
+		}
+		if nested, ok := v.(map[string]interface{}); ok && len(nested) > 0 {
+			flatten(key, nested, sep, out)
+			continue
+		}
+		out[key] = normalize(v)
+	}
+}
+
+// normalize converts json.Number values to int64 or float64, recursively.
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, e := range t {
+			out[i] = normalize(e)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			out[k] = normalize(e)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,208 @@
+package loaders
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+// DefaultMaxRows caps the rows a SQLLoader returns unless configured otherwise.
+const DefaultMaxRows = 100000
+
+// SQLLoader runs a query through database/sql. The driver must be imported
+// by the application (e.g. _ "github.com/lib/pq").
+//
+// Config keys:
+//
+//	driver        database/sql driver name (required unless DB is set)
+//	dsn           data source name; "connection" is accepted as an alias
+//	query         SQL to run (required)
+//	params        comma-separated parameter names bound positionally
+//	named_params  comma-separated parameter names bound with sql.Named
+//	param.<name>  parameter value; overrides ReportJob.Params
+//	max_rows      row cap, "0" or "-1" for unlimited (default DefaultMaxRows)
+//	on_max_rows   "truncate" (default) returns the first max_rows rows,
+//	              "error" fails the load when the query returns more
+//
+// Parameter values come from the job's Params, so one query can serve
+// several jobs.
+type SQLLoader struct {
+	// DB, when set, is used instead of opening driver/dsn on every load.
+	DB *sql.DB
+	// MaxRows overrides DefaultMaxRows when the config has no max_rows.
+	// 0 keeps the default and -1 (any negative value) means unlimited.
+	MaxRows int
+}
+
+func (l SQLLoader) Load(ctx context.Context, cfg cronyx.DataSourceConfig) (cronyx.DataPayload, error) {
+	query := cfg["query"]
+	if query == "" {
+		return cronyx.DataPayload{}, fmt.Errorf("sql loader: query is required")
+	}
+
+	maxRows := l.MaxRows
+	if maxRows == 0 {
+		maxRows = DefaultMaxRows
+	}
+	if v := cfg["max_rows"]; v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return cronyx.DataPayload{}, fmt.Errorf("sql loader: invalid max_rows %q", v)
+		}
+		maxRows = n
+		if n == 0 {
+			maxRows = -1
+		}
+	}
+	strict := false
+	switch v := cfg["on_max_rows"]; v {
+	case "", "truncate":
+	case "error":
+		strict = true
+	default:
+		return cronyx.DataPayload{}, fmt.Errorf("sql loader: invalid on_max_rows %q", v)
+	}
+
+	args, err := queryArgs(ctx, cfg)
+	if err != nil {
+		return cronyx.DataPayload{}, err
+	}
+
+	db := l.DB
+	if db == nil {
+		dsn := cfg["dsn"]
+		if dsn == "" {
+			dsn = cfg["connection"]
+		}
+		if cfg["driver"] == "" || dsn == "" {
+			return cronyx.DataPayload{}, fmt.Errorf("sql loader: driver and dsn are required")
+		}
+		db, err = sql.Open(cfg["driver"], dsn)
+		if err != nil {
+			return cronyx.DataPayload{}, fmt.Errorf("sql loader: failed to open database: %w", err)
+		}
+		defer db.Close()
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return cronyx.DataPayload{}, fmt.Errorf("sql loader: query failed: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return cronyx.DataPayload{}, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return cronyx.DataPayload{}, err
+	}
+
+	var out []map[string]interface{}
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if maxRows > 0 && len(out) >= maxRows {
+			if strict {
+				return cronyx.DataPayload{}, fmt.Errorf("sql loader: query returned more than %d rows", maxRows)
+			}
+			break
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return cronyx.DataPayload{}, fmt.Errorf("sql loader: scan failed: %w", err)
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			row[col] = convertSQLValue(values[i], types[i].DatabaseTypeName())
+		}
+		out = append(out, row)
+	}
+	if err := rows.Err(); err != nil {
+		return cronyx.DataPayload{}, fmt.Errorf("sql loader: %w", err)
+	}
+	return cronyx.DataPayload{Rows: out, Columns: columns}, nil
+}
+
+// queryArgs builds the query arguments from the params/named_params lists.
+func queryArgs(ctx context.Context, cfg cronyx.DataSourceConfig) ([]interface{}, error) {
+	var jobParams map[string]string
+	if info, ok := cronyx.RunInfoFromContext(ctx); ok {
+		jobParams = info.Job.Params
+	}
+	lookup := func(name string) (string, error) {
+		if v, ok := cfg["param."+name]; ok {
+			return v, nil
+		}
+		if v, ok := jobParams[name]; ok {
+			return v, nil
+		}
+		return "", fmt.Errorf("sql loader: no value for parameter %q", name)
+	}
+
+	var args []interface{}
+	for _, name := range splitList(cfg["params"]) {
+		v, err := lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	for _, name := range splitList(cfg["named_params"]) {
+		v, err := lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, sql.Named(name, v))
+	}
+	return args, nil
+}
+
+// convertSQLValue maps driver values to Go values. Drivers that return
+// numeric or boolean columns as bytes are converted by database type name.
+func convertSQLValue(v interface{}, dbType string) interface{} {
+	b, ok := v.([]byte)
+	if !ok {
+		return v
+	}
+	s := string(b)
+
+	typ := strings.ToUpper(dbType)
+	switch {
+	case strings.Contains(typ, "INT"):
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+	case strings.Contains(typ, "DEC"), strings.Contains(typ, "NUMERIC"),
+		strings.Contains(typ, "REAL"), strings.Contains(typ, "FLOAT"),
+		strings.Contains(typ, "DOUBLE"), strings.Contains(typ, "MONEY"):
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case strings.Contains(typ, "BOOL"):
+		if bv, err := strconv.ParseBool(s); err == nil {
+			return bv
+		}
+	case strings.Contains(typ, "BLOB"), strings.Contains(typ, "BINARY"), typ == "BYTEA":
+		return b
+	}
+	return s
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,314 @@
+package loaders
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+// fakeDriver is a minimal database/sql driver. The DSN names a table
+// registered with fakeTables; every query returns all of its rows and is
+// recorded along with its arguments.
+type fakeDriver struct{}
+
+type fakeTable struct {
+	columns []string
+	types   []string
+	rows    [][]driver.Value
+
+	mu    sync.Mutex
+	query string
+	args  []driver.NamedValue
+}
+
+var (
+	fakeMu     sync.Mutex
+	fakeTables = map[string]*fakeTable{}
+)
+
+func init() { sql.Register("fake", fakeDriver{}) }
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	tbl, ok := fakeTables[name]
+	if !ok {
+		return nil, fmt.Errorf("fake: no table %q", name)
+	}
+	return fakeConn{tbl}, nil
+}
+
+type fakeConn struct{ tbl *fakeTable }
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("fake: prepared statements are not supported")
+}
+func (fakeConn) Close() error { return nil }
+func (fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("fake: transactions are not supported")
+}
+
+func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.tbl.mu.Lock()
+	c.tbl.query, c.tbl.args = query, args
+	c.tbl.mu.Unlock()
+	return &fakeRows{tbl: c.tbl}, nil
+}
+
+type fakeRows struct {
+	tbl  *fakeTable
+	next int
+}
+
+func (r *fakeRows) Columns() []string { return r.tbl.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(i int) string { return r.tbl.types[i] }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.tbl.rows) {
+		return io.EOF
+	}
+	copy(dest, r.tbl.rows[r.next])
+	r.next++
+	return nil
+}
+
+// openTestDB returns a fake database with a sales table of n rows. Decimal
+// amounts come back as text, the way many drivers return them.
+func openTestDB(t *testing.T, n int) (*sql.DB, *fakeTable) {
+	t.Helper()
+	tbl := &fakeTable{
+		columns: []string{"id", "region", "amount", "note", "raw"},
+		types:   []string{"INTEGER", "TEXT", "DECIMAL", "TEXT", "BLOB"},
+	}
+	for i := 1; i <= n; i++ {
+		region := "north"
+		if i%2 == 0 {
+			region = "south"
+		}
+		tbl.rows = append(tbl.rows, []driver.Value{int64(i), region, []byte(fmt.Sprintf("%d.5", i)), nil, []byte{1, 2}})
+	}
+	fakeMu.Lock()
+	fakeTables[t.Name()] = tbl
+	fakeMu.Unlock()
+	t.Cleanup(func() {
+		fakeMu.Lock()
+		delete(fakeTables, t.Name())
+		fakeMu.Unlock()
+	})
+
+	db, err := sql.Open("fake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, tbl
+}
+
+func TestSQLLoaderTypes(t *testing.T) {
+	db, _ := openTestDB(t, 1)
+	p, err := SQLLoader{DB: db}.Load(context.Background(), cronyx.DataSourceConfig{
+		"query": "SELECT id, region, amount, note, raw FROM sales",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"id", "region", "amount", "note", "raw"}; !reflect.DeepEqual(p.Columns, want) {
+		t.Errorf("Columns = %v, want %v", p.Columns, want)
+	}
+	if len(p.Rows) != 1 {
+		t.Fatalf("got %d rows, want 1", len(p.Rows))
+	}
+
+	row := p.Rows[0]
+	tests := []struct {
+		col  string
+		want interface{}
+	}{
+		{"id", int64(1)},
+		{"region", "north"},
+		{"amount", 1.5},
+		{"note", nil},
+		{"raw", []byte{1, 2}},
+	}
+	for _, tt := range tests {
+		if got := row[tt.col]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %#v (%T), want %#v (%T)", tt.col, got, got, tt.want, tt.want)
+		}
+	}
+}
+
+func TestConvertSQLValue(t *testing.T) {
+	tests := []struct {
+		v      interface{}
+		dbType string
+		want   interface{}
+	}{
+		{[]byte("42"), "BIGINT", int64(42)},
+		{[]byte("-7"), "int4", int64(-7)},
+		{[]byte("12.50"), "DECIMAL", 12.5},
+		{[]byte("3.25"), "NUMERIC", 3.25},
+		{[]byte("1e3"), "DOUBLE", 1000.0},
+		{[]byte("0.5"), "FLOAT8", 0.5},
+		{[]byte("true"), "BOOL", true},
+		{[]byte("0"), "BOOLEAN", false},
+		{[]byte("abc"), "VARCHAR", "abc"},
+		{[]byte("not a number"), "INT", "not a number"},
+		{[]byte{0, 1}, "BLOB", []byte{0, 1}},
+		{[]byte{0xff}, "BYTEA", []byte{0xff}},
+		{[]byte{1}, "VARBINARY", []byte{1}},
+		{int64(5), "INTEGER", int64(5)},
+		{nil, "TEXT", nil},
+	}
+	for _, tt := range tests {
+		if got := convertSQLValue(tt.v, tt.dbType); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("convertSQLValue(%#v, %s) = %#v, want %#v", tt.v, tt.dbType, got, tt.want)
+		}
+	}
+}
+
+func TestSQLLoaderParams(t *testing.T) {
+	db, tbl := openTestDB(t, 4)
+	job := cronyx.ReportJob{Params: map[string]string{"region": "south", "year": "2025"}}
+	ctx := cronyx.ContextWithRunInfo(context.Background(), cronyx.RunInfo{Job: job})
+
+	tests := []struct {
+		name string
+		cfg  cronyx.DataSourceConfig
+		want []driver.NamedValue
+	}{
+		{
+			name: "positional from job params",
+			cfg:  cronyx.DataSourceConfig{"query": "SELECT id FROM sales WHERE region = ? AND year = ?", "params": "region, year"},
+			want: []driver.NamedValue{{Ordinal: 1, Value: "south"}, {Ordinal: 2, Value: "2025"}},
+		},
+		{
+			name: "named from job params",
+			cfg:  cronyx.DataSourceConfig{"query": "SELECT id FROM sales WHERE region = :region", "named_params": "region"},
+			want: []driver.NamedValue{{Name: "region", Ordinal: 1, Value: "south"}},
+		},
+		{
+			name: "config overrides job params",
+			cfg: cronyx.DataSourceConfig{
+				"query":        "SELECT id FROM sales WHERE region = ?",
+				"params":       "region",
+				"param.region": "north",
+			},
+			want: []driver.NamedValue{{Ordinal: 1, Value: "north"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := (SQLLoader{DB: db}).Load(ctx, tt.cfg); err != nil {
+				t.Fatal(err)
+			}
+			tbl.mu.Lock()
+			defer tbl.mu.Unlock()
+			if tbl.query != tt.cfg["query"] {
+				t.Errorf("query = %q, want %q", tbl.query, tt.cfg["query"])
+			}
+			if !reflect.DeepEqual(tbl.args, tt.want) {
+				t.Errorf("args = %+v, want %+v", tbl.args, tt.want)
+			}
+		})
+	}
+
+	_, err := SQLLoader{DB: db}.Load(context.Background(), cronyx.DataSourceConfig{
+		"query": "SELECT id FROM sales WHERE region = ?", "params": "region",
+	})
+	if err == nil || !strings.Contains(err.Error(), `no value for parameter "region"`) {
+		t.Errorf("missing parameter: err = %v", err)
+	}
+}
+
+func TestSQLLoaderMaxRows(t *testing.T) {
+	db, _ := openTestDB(t, 5)
+	tests := []struct {
+		name    string
+		maxRows int
+		cfg     cronyx.DataSourceConfig
+		want    int
+		wantErr string
+	}{
+		{name: "default cap", want: 5},
+		{name: "config cap truncates", cfg: cronyx.DataSourceConfig{"max_rows": "3"}, want: 3},
+		{name: "field cap truncates", maxRows: 2, want: 2},
+		{name: "config overrides field", maxRows: 2, cfg: cronyx.DataSourceConfig{"max_rows": "4"}, want: 4},
+		{name: "config zero is unlimited", maxRows: 2, cfg: cronyx.DataSourceConfig{"max_rows": "0"}, want: 5},
+		{name: "negative field is unlimited", maxRows: -1, want: 5},
+		{name: "cap not reached", cfg: cronyx.DataSourceConfig{"max_rows": "5", "on_max_rows": "error"}, want: 5},
+		{
+			name:    "error when exceeded",
+			cfg:     cronyx.DataSourceConfig{"max_rows": "3", "on_max_rows": "error"},
+			wantErr: "more than 3 rows",
+		},
+		{name: "invalid max_rows", cfg: cronyx.DataSourceConfig{"max_rows": "many"}, wantErr: "invalid max_rows"},
+		{name: "invalid on_max_rows", cfg: cronyx.DataSourceConfig{"on_max_rows": "warn"}, wantErr: "invalid on_max_rows"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := cronyx.DataSourceConfig{"query": "SELECT id FROM sales ORDER BY id"}
+			for k, v := range tt.cfg {
+				cfg[k] = v
+			}
+			p, err := SQLLoader{DB: db, MaxRows: tt.maxRows}.Load(context.Background(), cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(p.Rows) != tt.want {
+				t.Errorf("got %d rows, want %d", len(p.Rows), tt.want)
+			}
+			if len(p.Rows) > 0 && p.Rows[0]["id"] != int64(1) {
+				t.Errorf("first row = %v, want id 1", p.Rows[0])
+			}
+		})
+	}
+}
+
+func TestSQLLoaderOpensDriver(t *testing.T) {
+	openTestDB(t, 2)
+	p, err := SQLLoader{}.Load(context.Background(), cronyx.DataSourceConfig{
+		"driver": "fake",
+		"dsn":    t.Name(),
+		"query":  "SELECT * FROM sales",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Rows) != 2 || p.Rows[1]["id"] != int64(2) {
+		t.Errorf("Rows = %v, want ids 1 and 2", p.Rows)
+	}
+
+	_, err = SQLLoader{}.Load(context.Background(), cronyx.DataSourceConfig{"driver": "fake", "query": "SELECT 1"})
+	if err == nil || !strings.Contains(err.Error(), "driver and dsn are required") {
+		t.Errorf("no dsn: err = %v", err)
+	}
+}
+
+func TestSQLLoaderCancelled(t *testing.T) {
+	db, _ := openTestDB(t, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := (SQLLoader{DB: db}).Load(ctx, cronyx.DataSourceConfig{"query": "SELECT id FROM sales"}); err == nil {
+		t.Error("Load with a cancelled context succeeded")
+	}
+}
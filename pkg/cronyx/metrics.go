@@ -0,0 +1,335 @@
+package cronyx
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the histogram upper bounds, in seconds.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// Histogram is a snapshot of a latency histogram. Counts[i] is the number
+// of observations <= Bounds[i] (cumulative, as in Prometheus); Count
+// includes observations above the last bound.
+type Histogram struct {
+	Bounds []float64 // upper bounds in seconds
+	Counts []uint64
+	Count  uint64
+	Sum    time.Duration
+}
+
+// Avg returns the mean observation, or zero if there are none.
+func (h Histogram) Avg() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// JobMetrics holds the counters for a single job ID.
+type JobMetrics struct {
+	// Runs counts finished runs by status, including runs that never
+	// started (skipped, dropped, abandoned or cancelled while waiting).
+	Runs            map[RunStatus]uint64
+	RowsLoaded      uint64
+	DroppedTriggers uint64    // triggers dropped by the overflow policy
+	Duration        Histogram // runs that started
+}
+
+// Metrics is a point-in-time snapshot of engine metrics, returned by
+// Engine.GetMetrics.
+type Metrics struct {
+	TotalJobs      uint64 // finished runs, whether they started or not
+	SuccessfulJobs uint64
+	FailedJobs     uint64
+	AvgDuration    time.Duration // of the runs that started
+
+	QueueDepth    int // queued runs plus runs held by concurrency limits
+	QueueCapacity int
+	BusyWorkers   int
+	Workers       int
+
+	RowsLoaded      uint64
+	DroppedTriggers uint64
+	BytesGenerated  map[string]uint64 // by output format
+
+	Jobs   map[string]JobMetrics
+	Stages map[Stage]Histogram
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative; last slot is +Inf
+	count  uint64
+	sum    time.Duration
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]uint64, len(latencyBuckets)+1)}
+}
+
+func (h *histogram) observe(d time.Duration) {
+	secs := d.Seconds()
+	i := sort.SearchFloat64s(latencyBuckets, secs)
+	h.counts[i]++
+	h.count++
+	h.sum += d
+}
+
+func (h *histogram) snapshot() Histogram {
+	s := Histogram{
+		Bounds: latencyBuckets,
+		Counts: make([]uint64, len(latencyBuckets)),
+		Count:  h.count,
+		Sum:    h.sum,
+	}
+	var cum uint64
+	for i := range latencyBuckets {
+		cum += h.counts[i]
+		s.Counts[i] = cum
+	}
+	return s
+}
+
+type jobCounters struct {
+	runs     map[RunStatus]uint64
+	rows     uint64
+	dropped  uint64
+	duration *histogram
+}
+
+// metrics collects engine counters. A nil *metrics ignores observations,
+// which is how disabled metrics are represented.
+type metrics struct {
+	mu     sync.Mutex
+	jobs   map[string]*jobCounters
+	stages map[Stage]*histogram
+	bytes  map[string]uint64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		jobs:   map[string]*jobCounters{},
+		stages: map[Stage]*histogram{},
+		bytes:  map[string]uint64{},
+	}
+}
+
+func (m *metrics) job(id string) *jobCounters {
+	jc, ok := m.jobs[id]
+	if !ok {
+		jc = &jobCounters{runs: map[RunStatus]uint64{}, duration: newHistogram()}
+		m.jobs[id] = jc
+	}
+	return jc
+}
+
+func (m *metrics) observeRun(jobID string, status RunStatus, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jc := m.job(jobID)
+	jc.runs[status]++
+	jc.duration.observe(d)
+}
+
+// observeUnstarted counts a run that ended without starting.
+func (m *metrics) observeUnstarted(jobID string, status RunStatus) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.job(jobID).runs[status]++
+}
+
+func (m *metrics) observeStage(stage Stage, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.stages[stage]
+	if !ok {
+		h = newHistogram()
+		m.stages[stage] = h
+	}
+	h.observe(d)
+}
+
+func (m *metrics) addRows(jobID string, n int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.job(jobID).rows += uint64(n)
+}
+
+func (m *metrics) addDropped(jobID string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.job(jobID).dropped++
+}
+
+func (m *metrics) addBytes(format string, n int64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bytes[format] += uint64(n)
+}
+
+// GetMetrics returns a snapshot of the engine metrics. Counters stay at zero
+// unless Config.EnableMetrics is set; queue and worker gauges are always
+// reported.
+func (e *Engine) GetMetrics() Metrics {
+	snap := Metrics{
+		QueueDepth:     len(e.slots),
+		QueueCapacity:  cap(e.slots),
+		BusyWorkers:    int(e.busyWorkers.Load()),
+		Workers:        e.workers,
+		BytesGenerated: map[string]uint64{},
+		Jobs:           map[string]JobMetrics{},
+		Stages:         map[Stage]Histogram{},
+	}
+
+	m := e.metrics
+	if m == nil {
+		return snap
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var total time.Duration
+	var timed uint64
+	for id, jc := range m.jobs {
+		jm := JobMetrics{
+			Runs:            map[RunStatus]uint64{},
+			RowsLoaded:      jc.rows,
+			DroppedTriggers: jc.dropped,
+			Duration:        jc.duration.snapshot(),
+		}
+		for status, n := range jc.runs {
+			jm.Runs[status] = n
+			snap.TotalJobs += n
+		}
+		snap.SuccessfulJobs += jc.runs[RunSucceeded]
+		snap.FailedJobs += jc.runs[RunFailed]
+		snap.RowsLoaded += jc.rows
+		snap.DroppedTriggers += jc.dropped
+		total += jc.duration.sum
+		timed += jc.duration.count
+		snap.Jobs[id] = jm
+	}
+	if timed > 0 {
+		snap.AvgDuration = total / time.Duration(timed)
+	}
+	for stage, h := range m.stages {
+		snap.Stages[stage] = h.snapshot()
+	}
+	for format, n := range m.bytes {
+		snap.BytesGenerated[format] = n
+	}
+	return snap
+}
+
+// MetricsHandler serves the engine metrics in the Prometheus text
+// exposition format.
+func (e *Engine) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		writePrometheus(w, e.GetMetrics())
+	})
+}
+
+func writePrometheus(w io.Writer, m Metrics) {
+	header := func(name, typ, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+
+	header("cronyx_runs_total", "counter", "Finished job runs by job and status.")
+	for _, id := range sortedKeys(m.Jobs) {
+		runs := m.Jobs[id].Runs
+		statuses := make([]string, 0, len(runs))
+		for status := range runs {
+			statuses = append(statuses, string(status))
+		}
+		sort.Strings(statuses)
+		for _, status := range statuses {
+			fmt.Fprintf(w, "cronyx_runs_total{job=%s,status=%s} %d\n",
+				promLabel(id), promLabel(status), runs[RunStatus(status)])
+		}
+	}
+
+	header("cronyx_run_duration_seconds", "histogram", "Job run duration by job.")
+	for _, id := range sortedKeys(m.Jobs) {
+		writeHistogram(w, "cronyx_run_duration_seconds", "job="+promLabel(id), m.Jobs[id].Duration)
+	}
+
+	header("cronyx_stage_duration_seconds", "histogram", "Pipeline stage latency.")
+	for _, stage := range []Stage{StageLoad, StageRender, StageOutput, StageDeliver} {
+		if h, ok := m.Stages[stage]; ok {
+			writeHistogram(w, "cronyx_stage_duration_seconds", "stage="+promLabel(string(stage)), h)
+		}
+	}
+
+	header("cronyx_rows_loaded_total", "counter", "Rows returned by loaders, by job.")
+	for _, id := range sortedKeys(m.Jobs) {
+		fmt.Fprintf(w, "cronyx_rows_loaded_total{job=%s} %d\n", promLabel(id), m.Jobs[id].RowsLoaded)
+	}
+
+	header("cronyx_triggers_dropped_total", "counter", "Triggers dropped because the job queue was full, by job.")
+	for _, id := range sortedKeys(m.Jobs) {
+		fmt.Fprintf(w, "cronyx_triggers_dropped_total{job=%s} %d\n", promLabel(id), m.Jobs[id].DroppedTriggers)
+	}
+
+	header("cronyx_output_bytes_total", "counter", "Bytes written by output generators, by format.")
+	for _, format := range sortedKeys(m.BytesGenerated) {
+		fmt.Fprintf(w, "cronyx_output_bytes_total{format=%s} %d\n", promLabel(format), m.BytesGenerated[format])
+	}
+
+	header("cronyx_queue_depth", "gauge", "Runs waiting in the job queue or held by concurrency limits.")
+	fmt.Fprintf(w, "cronyx_queue_depth %d\n", m.QueueDepth)
+	header("cronyx_queue_capacity", "gauge", "Size of the job queue.")
+	fmt.Fprintf(w, "cronyx_queue_capacity %d\n", m.QueueCapacity)
+	header("cronyx_workers_busy", "gauge", "Workers currently executing a run.")
+	fmt.Fprintf(w, "cronyx_workers_busy %d\n", m.BusyWorkers)
+	header("cronyx_workers", "gauge", "Configured workers.")
+	fmt.Fprintf(w, "cronyx_workers %d\n", m.Workers)
+}
+
+func writeHistogram(w io.Writer, name, labels string, h Histogram) {
+	for i, bound := range h.Bounds {
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"%g\"} %d\n", name, labels, bound, h.Counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.Count)
+	fmt.Fprintf(w, "%s_sum{%s} %g\n", name, labels, h.Sum.Seconds())
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.Count)
+}
+
+var promEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// promLabel quotes and escapes a label value.
+func promLabel(v string) string {
+	return `"` + promEscaper.Replace(v) + `"`
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,268 @@
+package outputs
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+	bf "github.com/russross/blackfriday/v2"
+)
+
+// contentFormat reports the syntax of r.Content as set by the renderer.
+// Documents from renderers that don't say are treated as Markdown.
+func contentFormat(r cronyx.RenderedDoc) string {
+	if f, ok := r.Meta["format"].(string); ok && f != "" {
+		return f
+	}
+	return "markdown"
+}
+
+// markdownOutput returns the source document for the md format.
+func markdownOutput(r cronyx.RenderedDoc) string {
+	if r.Content != "" {
+		return r.Content
+	}
+	return r.HTML
+}
+
+// PlainText renders r as plain text without markup, e.g. for email bodies.
+func PlainText(r cronyx.RenderedDoc) string {
+	if r.Content == "" {
+		return htmlToText(r.HTML)
+	}
+	switch contentFormat(r) {
+	case "text":
+		return r.Content
+	case "html":
+		return htmlToText(r.Content)
+	default:
+		return markdownToText(r.Content)
+	}
+}
+
+// HTMLDocument wraps the rendered HTML in a standalone document titled
+// after the job in ctx, embedding css when given. Templates that already
+// produce a full document are returned unchanged.
+func HTMLDocument(ctx context.Context, r cronyx.RenderedDoc, css string) string {
+	body := r.HTML
+	head := strings.ToLower(strings.TrimSpace(body))
+	if strings.HasPrefix(head, "<!doctype") || strings.HasPrefix(head, "<html") {
+		return body
+	}
+
+	title := "Report"
+	if info, ok := cronyx.RunInfoFromContext(ctx); ok && info.Job.Name != "" {
+		title = info.Job.Name
+	}
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	b.WriteString("<meta charset=\"utf-8\">\n")
+	b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(title))
+	if css != "" {
+		// </style> is the only sequence that can end the element early
+		css = strings.ReplaceAll(css, "</style", `<\/style`)
+		fmt.Fprintf(&b, "<style>\n%s\n</style>\n", strings.TrimSpace(css))
+	}
+	b.WriteString("</head>\n<body>\n")
+	b.WriteString(strings.TrimRight(body, "\n"))
+	b.WriteString("\n</body>\n</html>\n")
+	return b.String()
+}
+
+var (
+	htmlBreaks     = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|h[1-6]|li|tr|pre|blockquote|table|ul|ol)>`)
+	htmlCells      = regexp.MustCompile(`(?i)</t[dh]>`)
+	htmlSkipped    = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)>`)
+	extraNewlines  = regexp.MustCompile(`\n{3,}`)
+	trailingSpaces = regexp.MustCompile(`[ \t]+\n`)
+)
+
+// htmlToText drops tags and decodes entities, keeping block boundaries as
+// line breaks.
+func htmlToText(s string) string {
+	s = htmlSkipped.ReplaceAllString(s, "")
+	s = htmlBreaks.ReplaceAllString(s, "\n")
+	s = htmlCells.ReplaceAllString(s, "\t")
+	s = html.UnescapeString(stripTags(s))
+	s = trailingSpaces.ReplaceAllString(s, "\n")
+	s = extraNewlines.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s) + "\n"
+}
+
+// markdownToText renders Markdown as plain text: emphasis markers are
+// dropped, links show their target, lists and quotes keep simple prefixes
+// and tables are aligned in columns.
+func markdownToText(src string) string {
+	doc := bf.New(bf.WithExtensions(bf.CommonExtensions)).Parse([]byte(src))
+	return strings.Join(textBlocks(doc), "\n\n") + "\n"
+}
+
+func textBlocks(n *bf.Node) []string {
+	var blocks []string
+	for c := n.FirstChild; c != nil; c = c.Next {
+		if text := textBlock(c); text != "" {
+			blocks = append(blocks, text)
+		}
+	}
+	return blocks
+}
+
+func textBlock(n *bf.Node) string {
+	switch n.Type {
+	case bf.Heading:
+		text := inlineText(n)
+		switch n.HeadingData.Level {
+		case 1:
+			return text + "\n" + strings.Repeat("=", utf8.RuneCountInString(text))
+		case 2:
+			return text + "\n" + strings.Repeat("-", utf8.RuneCountInString(text))
+		}
+		return text
+	case bf.Paragraph:
+		return inlineText(n)
+	case bf.List:
+		return listText(n)
+	case bf.BlockQuote:
+		return prefixLines(strings.Join(textBlocks(n), "\n\n"), "> ", "> ")
+	case bf.CodeBlock:
+		return prefixLines(strings.TrimRight(string(n.Literal), "\n"), "    ", "    ")
+	case bf.Table:
+		return tableText(n)
+	case bf.HorizontalRule:
+		return strings.Repeat("-", 40)
+	case bf.HTMLBlock:
+		return strings.TrimSpace(htmlToText(string(n.Literal)))
+	}
+	return strings.Join(textBlocks(n), "\n\n")
+}
+
+func listText(n *bf.Node) string {
+	sep := "\n\n"
+	if n.Tight {
+		sep = "\n"
+	}
+	var items []string
+	counter := 1
+	for item := n.FirstChild; item != nil; item = item.Next {
+		marker := "- "
+		if n.ListFlags&bf.ListTypeOrdered != 0 {
+			marker = fmt.Sprintf("%d. ", counter)
+			counter++
+		}
+		body := strings.Join(textBlocks(item), sep)
+		items = append(items, prefixLines(body, marker, strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, sep)
+}
+
+// prefixLines prefixes the first line of s with first and the other
+// non-empty lines with rest.
+func prefixLines(s, first, rest string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			lines[i] = first + line
+		case line != "":
+			lines[i] = rest + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func tableText(n *bf.Node) string {
+	var rows [][]string
+	var aligns []bf.CellAlignFlags
+	header := 0
+	for section := n.FirstChild; section != nil; section = section.Next {
+		for row := section.FirstChild; row != nil; row = row.Next {
+			var cells []string
+			for cell := row.FirstChild; cell != nil; cell = cell.Next {
+				cells = append(cells, strings.ReplaceAll(inlineText(cell), "\n", " "))
+				if len(cells) > len(aligns) {
+					aligns = append(aligns, cell.Align)
+				}
+			}
+			rows = append(rows, cells)
+			if section.Type == bf.TableHead {
+				header = len(rows)
+			}
+		}
+	}
+
+	widths := make([]int, len(aligns))
+	for _, row := range rows {
+		for i, cell := range row {
+			if w := utf8.RuneCountInString(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	var lines []string
+	for r, row := range rows {
+		cells := make([]string, len(widths))
+		for i := range widths {
+			var cell string
+			if i < len(row) {
+				cell = row[i]
+			}
+			pad := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			if aligns[i] == bf.TableAlignmentRight {
+				cells[i] = pad + cell
+			} else {
+				cells[i] = cell + pad
+			}
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(cells, "  "), " "))
+		if r == header-1 {
+			rules := make([]string, len(widths))
+			for i, w := range widths {
+				rules[i] = strings.Repeat("-", w)
+			}
+			lines = append(lines, strings.Join(rules, "  "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// inlineText flattens inline markup to its text.
+func inlineText(n *bf.Node) string {
+	var b strings.Builder
+	var walk func(n *bf.Node)
+	walk = func(n *bf.Node) {
+		for c := n.FirstChild; c != nil; c = c.Next {
+			switch c.Type {
+			case bf.Text, bf.Code:
+				b.Write(c.Literal)
+			case bf.Softbreak, bf.Hardbreak:
+				b.WriteString("\n")
+			case bf.Link:
+				start := b.Len()
+				walk(c)
+				text := b.String()[start:]
+				dest := string(c.LinkData.Destination)
+				if dest != "" && dest != text && dest != "mailto:"+text {
+					fmt.Fprintf(&b, " (%s)", dest)
+				}
+			case bf.Image:
+				alt := textContent(c)
+				if alt == "" {
+					alt = string(c.LinkData.Destination)
+				}
+				fmt.Fprintf(&b, "[image: %s]", alt)
+			case bf.HTMLSpan:
+			default:
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,211 @@
+package outputs
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+// exportTimeLayout formats time values in every export format.
+const exportTimeLayout = time.RFC3339Nano
+
+// DataExportGenerator writes the loaded rows (RenderedDoc.Data) as csv,
+// tsv, json or ndjson. Columns keep the loader's order, or are sorted by
+// name when the loader doesn't report one. Times are written as RFC 3339
+// with fractional seconds when present, and JSON leaves <, > and &
+// unescaped.
+type DataExportGenerator struct {
+	OutDir string
+	// FileName is the file name template (default DefaultFileName).
+	FileName string
+	// Format overrides the format the generator was registered under.
+	Format string
+	// Columns selects and orders the exported columns (default: all).
+	Columns []string
+	// Set exports a named DataPayload.Sets entry instead of the main rows.
+	Set string
+	// NoHeader omits the header line from csv and tsv files.
+	NoHeader bool
+}
+
+func (g DataExportGenerator) Generate(ctx context.Context, r cronyx.RenderedDoc, format string) (cronyx.OutputFile, error) {
+	format = valueOr(g.Format, format)
+
+	cols, rows := r.Data.Columns, r.Data.Rows
+	if g.Set != "" {
+		found := false
+		for _, set := range r.Data.Sets {
+			if set.Name == g.Set {
+				cols, rows, found = set.Columns, set.Rows, true
+				break
+			}
+		}
+		if !found {
+			return cronyx.OutputFile{}, fmt.Errorf("data set %q not found", g.Set)
+		}
+	}
+	if len(g.Columns) > 0 {
+		cols = g.Columns
+	} else {
+		cols = columnOrder(cols, rows)
+	}
+
+	var data []byte
+	var err error
+	switch format {
+	case "csv":
+		data, err = g.delimited(ctx, cols, rows, ',')
+	case "tsv":
+		data, err = g.delimited(ctx, cols, rows, '\t')
+	case "json":
+		data, err = jsonRows(ctx, cols, rows, false)
+	case "ndjson", "jsonl":
+		data, err = jsonRows(ctx, cols, rows, true)
+	default:
+		return cronyx.OutputFile{}, fmt.Errorf("unsupported export format %q", format)
+	}
+	if err != nil {
+		return cronyx.OutputFile{}, fmt.Errorf("failed to export %s: %w", format, err)
+	}
+	return writeOutput(ctx, g.OutDir, g.FileName, format, data)
+}
+
+func (g DataExportGenerator) delimited(ctx context.Context, cols []string, rows []map[string]interface{}, comma rune) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = comma
+	if !g.NoHeader {
+		w.Write(cols)
+	}
+	record := make([]string, len(cols))
+	for i, row := range rows {
+		if i%1000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		for j, col := range cols {
+			record[j] = formatCell(row[col])
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// formatCell renders a value for a csv or tsv cell.
+func formatCell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case time.Time:
+		return v.Format(exportTimeLayout)
+	case map[string]interface{}, []interface{}:
+		b, err := marshalJSON(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// jsonRows encodes rows as objects whose keys follow cols, either as one
+// indented array or as newline-delimited objects.
+func jsonRows(ctx context.Context, cols []string, rows []map[string]interface{}, ndjson bool) ([]byte, error) {
+	keys := make([][]byte, len(cols))
+	for i, col := range cols {
+		k, err := marshalJSON(col)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = k
+	}
+
+	var buf bytes.Buffer
+	if !ndjson {
+		buf.WriteString("[")
+	}
+	for i, row := range rows {
+		if i%1000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		switch {
+		case ndjson:
+		case i > 0:
+			buf.WriteString(",\n  ")
+		default:
+			buf.WriteString("\n  ")
+		}
+
+		buf.WriteByte('{')
+		for j, col := range cols {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			v, err := marshalJSON(jsonValue(row[col]))
+			if err != nil {
+				return nil, fmt.Errorf("row %d, column %q: %w", i, col, err)
+			}
+			buf.Write(keys[j])
+			buf.WriteByte(':')
+			buf.Write(v)
+		}
+		buf.WriteByte('}')
+		if ndjson {
+			buf.WriteByte('\n')
+		}
+	}
+	if !ndjson {
+		if len(rows) > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString("]\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// marshalJSON is json.Marshal without escaping <, > and &.
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// jsonValue makes values json.Marshal can't encode representable and
+// formats times like the csv export.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(exportTimeLayout)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil // NaN and Inf have no JSON form
+		}
+	}
+	return v
+}
@@ -0,0 +1,132 @@
+package outputs
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+func exportData() cronyx.DataPayload {
+	at := time.Date(2025, 3, 1, 9, 30, 0, 250_000_000, time.FixedZone("CET", 3600))
+	return cronyx.DataPayload{
+		Columns: []string{"name", "qty", "price", "at", "tags"},
+		Rows: []map[string]interface{}{
+			{"name": "Fish & <Chips>", "qty": 2, "price": 4.5, "at": at, "tags": []interface{}{"a&b"}},
+			{"name": "tab\there, \"quoted\"", "qty": nil, "price": math.NaN(), "at": at.Add(-250 * time.Millisecond)},
+		},
+		Sets: []cronyx.DataSet{
+			{Name: "totals", Rows: []map[string]interface{}{{"sum": 9, "count": 2}}},
+		},
+	}
+}
+
+func TestDataExportFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		gen    DataExportGenerator
+		want   string
+	}{
+		{
+			format: "csv",
+			want: "name,qty,price,at,tags\n" +
+				`Fish & <Chips>,2,4.5,2025-03-01T09:30:00.25+01:00,"[""a&b""]"` + "\n" +
+				`"tab	here, ""quoted""",,NaN,2025-03-01T09:30:00+01:00,` + "\n",
+		},
+		{
+			format: "tsv",
+			gen:    DataExportGenerator{Columns: []string{"qty", "name"}, NoHeader: true},
+			want:   "2\tFish & <Chips>\n\t\"tab\there, \"\"quoted\"\"\"\n",
+		},
+		{
+			format: "json",
+			want: "[\n" +
+				`  {"name":"Fish & <Chips>","qty":2,"price":4.5,"at":"2025-03-01T09:30:00.25+01:00","tags":["a&b"]},` + "\n" +
+				`  {"name":"tab\there, \"quoted\"","qty":null,"price":null,"at":"2025-03-01T09:30:00+01:00","tags":null}` + "\n" +
+				"]\n",
+		},
+		{
+			format: "ndjson",
+			gen:    DataExportGenerator{Columns: []string{"name"}},
+			want:   `{"name":"Fish & <Chips>"}` + "\n" + `{"name":"tab\there, \"quoted\""}` + "\n",
+		},
+		{
+			format: "jsonl",
+			gen:    DataExportGenerator{Set: "totals"},
+			want:   `{"count":2,"sum":9}` + "\n",
+		},
+		{
+			format: "csv",
+			gen:    DataExportGenerator{Format: "json", Set: "totals"},
+			want:   "[\n  {\"count\":2,\"sum\":9}\n]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			g := tt.gen
+			g.OutDir = t.TempDir()
+			g.FileName = "export.{{.Format}}"
+			f, err := g.Generate(context.Background(), cronyx.RenderedDoc{Data: exportData()}, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "export." + valueOr(tt.gen.Format, tt.format); f.Name != want {
+				t.Errorf("Name = %s, want %s", f.Name, want)
+			}
+			b, err := os.ReadFile(f.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestDataExportEmpty(t *testing.T) {
+	for format, want := range map[string]string{"csv": "\n", "json": "[]\n", "ndjson": ""} {
+		g := DataExportGenerator{OutDir: t.TempDir(), FileName: "empty.{{.Format}}"}
+		f, err := g.Generate(context.Background(), cronyx.RenderedDoc{}, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if string(f.Data) != want {
+			t.Errorf("%s: got %q, want %q", format, f.Data, want)
+		}
+	}
+}
+
+func TestDataExportErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		gen     DataExportGenerator
+		format  string
+		wantErr string
+	}{
+		{"format", DataExportGenerator{}, "xml", `unsupported export format "xml"`},
+		{"set", DataExportGenerator{Set: "nope"}, "csv", `data set "nope" not found`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := tt.gen
+			g.OutDir = t.TempDir()
+			_, err := g.Generate(context.Background(), cronyx.RenderedDoc{Data: exportData()}, tt.format)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	g := DataExportGenerator{OutDir: t.TempDir()}
+	if _, err := g.Generate(ctx, cronyx.RenderedDoc{Data: exportData()}, "csv"); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: err = %v, want context.Canceled", err)
+	}
+}
@@ -3,49 +3,53 @@ package outputs
 import (
 	"context"
 	"fmt"
-	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
 	"io/ioutil"
-	"os"
-	"path/filepath"
-	"time"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
 )
 
+// FileOutputGenerator writes the rendered document in the requested
+// format: "html" as a standalone page, "md" as the source Markdown, "txt"
+// as plain text, and pdf, xlsx and the data export formats through their
+// dedicated generators.
 type FileOutputGenerator struct {
 	OutDir string
+	// FileName is the file name template, which may include directories,
+	// e.g. `{{.JobID}}/{{.Date "2006/01/02"}}/{{.Slug}}.{{.Format}}`
+	// (default DefaultFileName). See NameData for the available fields.
+	FileName string
+	// CSS is embedded in the <style> element of html output. CSSFile names
+	// a stylesheet to embed instead.
+	CSS     string
+	CSSFile string
 }
 
 func (g FileOutputGenerator) Generate(ctx context.Context, r cronyx.RenderedDoc, format string) (cronyx.OutputFile, error) {
-	// Create output directory if it doesn't exist
-	if err := os.MkdirAll(g.OutDir, 0755); err != nil {
-		return cronyx.OutputFile{}, fmt.Errorf("failed to create output directory: %w", err)
-	}
-
-	// Generate filename with timestamp
-	timestamp := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("report_%s.%s", timestamp, format)
-	outPath := filepath.Join(g.OutDir, filename)
-
 	var data []byte
 	switch format {
 	case "html":
-		data = []byte(r.HTML)
-	case "pdf":
-		// Placeholder - in production, use a PDF generator
-		data = []byte(r.HTML)
+		css := g.CSS
+		if g.CSSFile != "" {
+			b, err := ioutil.ReadFile(g.CSSFile)
+			if err != nil {
+				return cronyx.OutputFile{}, fmt.Errorf("failed to read stylesheet: %w", err)
+			}
+			css = string(b)
+		}
+		data = []byte(HTMLDocument(ctx, r, css))
 	case "md":
-		// Extract original markdown if available, otherwise convert HTML back
-		data = []byte(r.HTML)
+		data = []byte(markdownOutput(r))
+	case "txt":
+		data = []byte(PlainText(r))
+	case "pdf":
+		return PDFOutputGenerator{OutDir: g.OutDir, FileName: g.FileName}.Generate(ctx, r, format)
+	case "xlsx":
+		return XLSXOutputGenerator{OutDir: g.OutDir, FileName: g.FileName}.Generate(ctx, r, format)
+	case "csv", "tsv", "json", "ndjson":
+		return DataExportGenerator{OutDir: g.OutDir, FileName: g.FileName}.Generate(ctx, r, format)
 	default:
 		data = []byte(r.HTML)
 	}
 
-	if err := ioutil.WriteFile(outPath, data, 0644); err != nil {
-		return cronyx.OutputFile{}, fmt.Errorf("failed to write output file: %w", err)
-	}
-
-	return cronyx.OutputFile{
-		Name: filename,
-		Path: outPath,
-		Data: data,
-	}, nil
+	return writeOutput(ctx, g.OutDir, g.FileName, format, data)
 }
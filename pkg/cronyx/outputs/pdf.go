@@ -0,0 +1,1044 @@
+package outputs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+	bf "github.com/russross/blackfriday/v2"
+)
+
+// Page sizes in points.
+var pageSizes = map[string][2]float64{
+	"a4":     {595.28, 841.89},
+	"a3":     {841.89, 1190.55},
+	"a5":     {419.53, 595.28},
+	"letter": {612, 792},
+	"legal":  {612, 1008},
+}
+
+// PDFOutputGenerator lays out the rendered Markdown (RenderedDoc.Content)
+// as a paginated PDF: headings, paragraphs, lists, block quotes, tables,
+// code blocks and local JPEG/PNG/GIF images. It needs no external tools.
+//
+// Text uses the built-in Helvetica and Courier fonts unless Fonts names
+// TrueType files to embed. Either way text is WinAnsi (Windows-1252)
+// encoded, so characters outside Western European scripts print as '?'
+// and are reported to Logger.
+type PDFOutputGenerator struct {
+	OutDir string
+	// FileName is the file name template (default DefaultFileName).
+	FileName string
+	// PageSize is "A4" (default), "A3", "A5", "Letter" or "Legal".
+	PageSize  string
+	Landscape bool
+	// Margin is the page margin in points (default 54, i.e. 3/4 inch).
+	Margin float64
+	// FontSize is the body text size in points (default 10).
+	FontSize float64
+	// Header is printed at the top of every page and defaults to the job
+	// name. Footer defaults to "Page {page} of {pages}".
+	Header string
+	Footer string
+	// NoHeaderFooter leaves the page margins empty.
+	NoHeaderFooter bool
+	// Fonts optionally embeds TrueType fonts.
+	Fonts PDFFonts
+	// ImageDir resolves relative image paths. It defaults to the template's
+	// directory.
+	ImageDir string
+	// Logger reports text the fonts cannot encode (default log.Default()).
+	Logger cronyx.Logger
+}
+
+func (g PDFOutputGenerator) Generate(ctx context.Context, r cronyx.RenderedDoc, format string) (cronyx.OutputFile, error) {
+	data, err := g.Render(ctx, r)
+	if err != nil {
+		return cronyx.OutputFile{}, fmt.Errorf("failed to build pdf: %w", err)
+	}
+	return writeOutput(ctx, g.OutDir, g.FileName, "pdf", data)
+}
+
+// Render returns the PDF bytes for r without writing a file.
+func (g PDFOutputGenerator) Render(ctx context.Context, r cronyx.RenderedDoc) ([]byte, error) {
+	l, err := g.newLayout(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	src := r.Content
+	if src == "" {
+		src = r.HTML
+	}
+	doc := bf.New(bf.WithExtensions(bf.CommonExtensions)).Parse([]byte(src))
+	for n := doc.FirstChild; n != nil; n = n.Next {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		l.block(n, 0)
+	}
+	data := l.build()
+	if len(l.missing) > 0 {
+		chars := make([]rune, 0, len(l.missing))
+		for r := range l.missing {
+			chars = append(chars, r)
+		}
+		sort.Slice(chars, func(i, j int) bool { return chars[i] < chars[j] })
+		logger := g.Logger
+		if logger == nil {
+			logger = log.Default()
+		}
+		logger.Printf("pdf output: %q has characters outside WinAnsi, printed as '?': %q", l.title, string(chars))
+	}
+	return data, nil
+}
+
+func (g PDFOutputGenerator) newLayout(ctx context.Context, r cronyx.RenderedDoc) (*pdfLayout, error) {
+	size, ok := pageSizes[strings.ToLower(valueOr(g.PageSize, "a4"))]
+	if !ok {
+		return nil, fmt.Errorf("unknown page size %q", g.PageSize)
+	}
+	if g.Landscape {
+		size[0], size[1] = size[1], size[0]
+	}
+	if g.Margin <= 0 {
+		g.Margin = 54
+	}
+	if g.FontSize <= 0 {
+		g.FontSize = 10
+	}
+
+	l := &pdfLayout{
+		g:       g,
+		width:   size[0],
+		height:  size[1],
+		images:  map[string]*pdfImage{},
+		size:    g.FontSize,
+		missing: map[rune]bool{},
+	}
+	l.left, l.right = g.Margin, size[0]-g.Margin
+	l.top, l.bottom = g.Margin, size[1]-g.Margin
+	if l.right-l.left < 100 || l.bottom-l.top < 100 {
+		return nil, fmt.Errorf("margin %v leaves no room on the page", g.Margin)
+	}
+
+	if info, ok := cronyx.RunInfoFromContext(ctx); ok {
+		l.title = info.Job.Name
+	}
+	if l.g.Header == "" {
+		l.g.Header = l.title
+	}
+	if l.g.Footer == "" {
+		l.g.Footer = "Page {page} of {pages}"
+	}
+
+	l.baseDir = g.ImageDir
+	if l.baseDir == "" {
+		if src, ok := r.Meta["source"].(string); ok && src != "" {
+			l.baseDir = filepath.Dir(src)
+		}
+	}
+
+	if err := l.loadFonts(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+type pdfLayout struct {
+	g     PDFOutputGenerator
+	title string
+	fonts [fontMono + 1]*pdfFont
+	used  [fontMono + 1]bool
+
+	images    map[string]*pdfImage
+	imageList []*pdfImage
+	baseDir   string
+
+	width, height            float64
+	left, right, top, bottom float64 // content box; top and bottom are measured from the page top
+
+	missing map[rune]bool // characters printed as '?'
+
+	pages  []*pdfPage
+	y      float64 // cursor, measured from the page top
+	size   float64 // body font size
+	gray   bool    // draw text in gray (block quotes)
+	tight  bool    // inside a tight list
+	marker *pdfMarker
+}
+
+type pdfPage struct {
+	ops   bytes.Buffer
+	links []pdfLink
+}
+
+type pdfLink struct {
+	x0, y0, x1, y1 float64 // in PDF coordinates
+	uri            string
+}
+
+// pdfMarker is a list bullet waiting for the item's first line.
+type pdfMarker struct {
+	text []byte
+	x    float64
+}
+
+// pdfSpan is a run of inline text with one style.
+type pdfSpan struct {
+	text  string
+	style fontStyle
+	link  string
+}
+
+// pdfWord is a piece of text that is laid out as a unit.
+type pdfWord struct {
+	text  []byte
+	style fontStyle
+	link  string
+	width float64
+	space bool // followed by a space
+	brk   bool // followed by a hard line break
+}
+
+type pdfCell struct {
+	spans  []pdfSpan
+	header bool
+	align  bf.CellAlignFlags
+}
+
+func (l *pdfLayout) loadFonts() error {
+	paths := [...]string{
+		fontRegular:    l.g.Fonts.Regular,
+		fontBold:       l.g.Fonts.Bold,
+		fontItalic:     l.g.Fonts.Italic,
+		fontBoldItalic: l.g.Fonts.BoldItalic,
+		fontMono:       l.g.Fonts.Mono,
+	}
+	for style, path := range paths {
+		if path == "" {
+			continue
+		}
+		f, err := loadTrueType(path)
+		if err != nil {
+			return fmt.Errorf("failed to load font: %w", err)
+		}
+		l.fonts[style] = f
+	}
+
+	// Fill the gaps from related faces before falling back to the
+	// built-in fonts, so a single Regular font covers the whole document.
+	fallback := func(style, from fontStyle) {
+		if l.fonts[style] == nil {
+			l.fonts[style] = l.fonts[from]
+		}
+	}
+	fallback(fontBoldItalic, fontBold)
+	fallback(fontBoldItalic, fontItalic)
+	fallback(fontBold, fontRegular)
+	fallback(fontItalic, fontRegular)
+	fallback(fontBoldItalic, fontRegular)
+	for style := range l.fonts {
+		if l.fonts[style] == nil {
+			l.fonts[style] = standardFont(fontStyle(style))
+		}
+	}
+	return nil
+}
+
+// encode converts s to WinAnsi, recording the characters it cannot encode.
+func (l *pdfLayout) encode(s string) []byte {
+	return encodeWinAnsi(s, func(r rune) { l.missing[r] = true })
+}
+
+// font returns the font for style and records that it is used.
+func (l *pdfLayout) font(style fontStyle) *pdfFont {
+	l.used[style] = true
+	return l.fonts[style]
+}
+
+func (l *pdfLayout) page() *pdfPage {
+	if len(l.pages) == 0 {
+		l.newPage()
+	}
+	return l.pages[len(l.pages)-1]
+}
+
+func (l *pdfLayout) newPage() {
+	l.pages = append(l.pages, &pdfPage{})
+	l.y = l.top
+}
+
+// ensure starts a new page unless h points fit below the cursor.
+func (l *pdfLayout) ensure(h float64) {
+	p := l.page()
+	if l.y+h > l.bottom && (l.y > l.top || p.ops.Len() > 0) {
+		l.newPage()
+	}
+}
+
+// space adds vertical space, except at the top of a page.
+func (l *pdfLayout) space(h float64) {
+	if l.y > l.top {
+		l.y += h
+	}
+}
+
+func (l *pdfLayout) lineHeight(size float64) float64 {
+	return size * 1.35
+}
+
+// text draws s with its baseline at y (measured from the page top).
+func (l *pdfLayout) text(x, y float64, style fontStyle, size float64, s []byte, rgb string) {
+	l.font(style)
+	fmt.Fprintf(&l.page().ops, "BT /F%d %s Tf %s rg %s %s Td %s Tj ET\n",
+		int(style)+1, num(size), rgb, num(x), num(l.height-y), pdfString(s))
+}
+
+func (l *pdfLayout) fillRect(x, y, w, h float64, rgb string) {
+	fmt.Fprintf(&l.page().ops, "%s rg %s %s %s %s re f\n", rgb, num(x), num(l.height-y-h), num(w), num(h))
+}
+
+func (l *pdfLayout) strokeRect(x, y, w, h float64, rgb string) {
+	fmt.Fprintf(&l.page().ops, "0.5 w %s RG %s %s %s %s re S\n", rgb, num(x), num(l.height-y-h), num(w), num(h))
+}
+
+func (l *pdfLayout) line(x0, y0, x1, y1, width float64, rgb string) {
+	fmt.Fprintf(&l.page().ops, "%s w %s RG %s %s m %s %s l S\n",
+		num(width), rgb, num(x0), num(l.height-y0), num(x1), num(l.height-y1))
+}
+
+const (
+	colorText  = "0 0 0"
+	colorGray  = "0.4 0.4 0.4"
+	colorLink  = "0.1 0.3 0.75"
+	colorRule  = "0.75 0.75 0.75"
+	colorShade = "0.94 0.94 0.94"
+	colorHead  = "0.88 0.9 0.93"
+)
+
+// block lays out a block-level node indented by indent points.
+func (l *pdfLayout) block(n *bf.Node, indent float64) {
+	switch n.Type {
+	case bf.Heading:
+		l.heading(n, indent)
+	case bf.Paragraph:
+		l.paragraph(n, indent)
+	case bf.List:
+		l.list(n, indent)
+	case bf.BlockQuote:
+		l.blockQuote(n, indent)
+	case bf.CodeBlock:
+		l.codeBlock(string(n.Literal), indent)
+	case bf.Table:
+		l.table(n, indent)
+	case bf.HorizontalRule:
+		l.ensure(l.size)
+		l.line(l.left+indent, l.y+l.size/2, l.right, l.y+l.size/2, 0.75, colorRule)
+		l.y += l.size
+	case bf.HTMLBlock:
+		if text := strings.TrimSpace(stripTags(string(n.Literal))); text != "" {
+			l.spans([]pdfSpan{{text: text}}, indent, l.size, l.size*0.6)
+		}
+	default:
+		for c := n.FirstChild; c != nil; c = c.Next {
+			l.block(c, indent)
+		}
+	}
+}
+
+var headingScale = [...]float64{1.9, 1.5, 1.25, 1.1, 1, 0.9}
+
+func (l *pdfLayout) heading(n *bf.Node, indent float64) {
+	level := n.HeadingData.Level
+	if level < 1 || level > len(headingScale) {
+		level = len(headingScale)
+	}
+	size := l.size * headingScale[level-1]
+
+	// Keep the heading together with the first lines that follow it.
+	l.space(size * 0.7)
+	l.ensure(l.lineHeight(size) + 2*l.lineHeight(l.size))
+	spans := l.inlineSpans(n, fontBold, indent)
+	l.spans(spans, indent, size, 0)
+	if level <= 2 {
+		l.line(l.left+indent, l.y+1, l.right, l.y+1, 0.5, colorRule)
+		l.y += 3
+	}
+	l.y += size * 0.4
+}
+
+func (l *pdfLayout) paragraph(n *bf.Node, indent float64) {
+	after := l.size * 0.6
+	if l.tight {
+		after = l.size * 0.2
+	}
+	spans := l.inlineSpans(n, fontRegular, indent)
+	l.spans(spans, indent, l.size, after)
+}
+
+// inlineSpans flattens the inline children of n. Images are laid out as
+// blocks of their own as they are found, flushing the text before them.
+func (l *pdfLayout) inlineSpans(n *bf.Node, style fontStyle, indent float64) []pdfSpan {
+	var spans []pdfSpan
+	var walk func(n *bf.Node, style fontStyle, link string)
+	walk = func(n *bf.Node, style fontStyle, link string) {
+		for c := n.FirstChild; c != nil; c = c.Next {
+			switch c.Type {
+			case bf.Text:
+				spans = append(spans, pdfSpan{text: string(c.Literal), style: style, link: link})
+			case bf.Code:
+				spans = append(spans, pdfSpan{text: string(c.Literal), style: fontMono, link: link})
+			case bf.Softbreak:
+				spans = append(spans, pdfSpan{text: " ", style: style})
+			case bf.Hardbreak:
+				spans = append(spans, pdfSpan{text: "\n", style: style})
+			case bf.Emph:
+				walk(c, withItalic(style), link)
+			case bf.Strong:
+				walk(c, withBold(style), link)
+			case bf.Link:
+				walk(c, style, string(c.LinkData.Destination))
+			case bf.Image:
+				if len(spans) > 0 {
+					l.spans(spans, indent, l.size, l.size*0.3)
+					spans = nil
+				}
+				l.image(c, indent)
+			case bf.HTMLSpan:
+				// inline tags are dropped, their text content is kept
+			default:
+				walk(c, style, link)
+			}
+		}
+	}
+	walk(n, style, "")
+	return spans
+}
+
+func withBold(s fontStyle) fontStyle {
+	switch s {
+	case fontItalic, fontBoldItalic:
+		return fontBoldItalic
+	case fontMono:
+		return fontMono
+	}
+	return fontBold
+}
+
+func withItalic(s fontStyle) fontStyle {
+	switch s {
+	case fontBold, fontBoldItalic:
+		return fontBoldItalic
+	case fontMono:
+		return fontMono
+	}
+	return fontItalic
+}
+
+// spans wraps and draws inline text, then adds after points of space.
+func (l *pdfLayout) spans(spans []pdfSpan, indent, size, after float64) {
+	if len(spans) == 0 {
+		return
+	}
+	x := l.left + indent
+	lh := l.lineHeight(size)
+	for _, line := range l.wrap(spans, size, l.right-x) {
+		l.ensure(lh)
+		baseline := l.y + size*0.93
+		l.drawMarker(baseline, size)
+		l.drawLine(line, x, baseline, size)
+		l.y += lh
+	}
+	l.y += after
+}
+
+// drawMarker draws a pending list bullet on the given baseline.
+func (l *pdfLayout) drawMarker(baseline, size float64) {
+	if l.marker == nil {
+		return
+	}
+	l.text(l.marker.x, baseline, fontRegular, size, l.marker.text, l.textColor())
+	l.marker = nil
+}
+
+func (l *pdfLayout) textColor() string {
+	if l.gray {
+		return colorGray
+	}
+	return colorText
+}
+
+// drawLine draws a wrapped line, one text run per style and link.
+func (l *pdfLayout) drawLine(line []pdfWord, x, baseline, size float64) {
+	for i := 0; i < len(line); {
+		run := append([]byte(nil), line[i].text...)
+		w := line[i].width
+		j := i + 1
+		for ; j < len(line) && line[j].style == line[i].style && line[j].link == line[i].link; j++ {
+			if line[j-1].space {
+				run = append(run, ' ')
+				w += l.fonts[line[i].style].width([]byte(" "), size)
+			}
+			run = append(run, line[j].text...)
+			w += line[j].width
+		}
+
+		rgb := l.textColor()
+		if line[i].link != "" {
+			rgb = colorLink
+			p := l.page()
+			p.links = append(p.links, pdfLink{
+				x0: x, y0: l.height - baseline - size*0.25,
+				x1: x + w, y1: l.height - baseline + size*0.8,
+				uri: line[i].link,
+			})
+		}
+		l.text(x, baseline, line[i].style, size, run, rgb)
+
+		x += w
+		if line[j-1].space {
+			x += l.fonts[line[j-1].style].width([]byte(" "), size)
+		}
+		i = j
+	}
+}
+
+// words splits spans into words, remembering where spaces and hard breaks
+// were so that pieces of differently styled text stay glued together.
+func (l *pdfLayout) words(spans []pdfSpan, size float64) []pdfWord {
+	var words []pdfWord
+	for _, s := range spans {
+		for i, part := range strings.Split(s.text, "\n") {
+			if i > 0 && len(words) > 0 {
+				words[len(words)-1].brk = true
+			}
+			if strings.TrimSpace(part) == "" {
+				if part != "" && len(words) > 0 {
+					words[len(words)-1].space = true
+				}
+				continue
+			}
+			if part[0] == ' ' || part[0] == '\t' {
+				if len(words) > 0 {
+					words[len(words)-1].space = true
+				}
+			}
+			fields := strings.Fields(part)
+			for j, f := range fields {
+				text := l.encode(f)
+				words = append(words, pdfWord{
+					text:  text,
+					style: s.style,
+					link:  s.link,
+					width: l.font(s.style).width(text, size),
+					space: j < len(fields)-1,
+				})
+			}
+			if last := part[len(part)-1]; last == ' ' || last == '\t' {
+				words[len(words)-1].space = true
+			}
+		}
+	}
+	return words
+}
+
+// wrap breaks spans into lines no wider than width.
+func (l *pdfLayout) wrap(spans []pdfSpan, size, width float64) [][]pdfWord {
+	words := l.words(spans, size)
+
+	var lines [][]pdfWord
+	var line []pdfWord
+	var lineWidth float64
+	flush := func() {
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+		line, lineWidth = nil, 0
+	}
+
+	for i := 0; i < len(words); {
+		// A group is a run of words without spaces between them.
+		j := i
+		groupWidth := words[j].width
+		for !words[j].space && !words[j].brk && j+1 < len(words) {
+			j++
+			groupWidth += words[j].width
+		}
+		group := words[i : j+1]
+		i = j + 1
+
+		gap := 0.0
+		if len(line) > 0 && line[len(line)-1].space {
+			gap = l.fonts[line[len(line)-1].style].width([]byte(" "), size)
+		}
+		if len(line) > 0 && lineWidth+gap+groupWidth > width {
+			flush()
+			gap = 0
+		}
+		if groupWidth > width {
+			for _, w := range group {
+				for _, piece := range l.splitWord(w, size, width) {
+					if len(line) > 0 && lineWidth+piece.width > width {
+						flush()
+					}
+					line = append(line, piece)
+					lineWidth += piece.width
+				}
+			}
+		} else {
+			line = append(line, group...)
+			lineWidth += gap + groupWidth
+		}
+		if group[len(group)-1].brk {
+			flush()
+		}
+	}
+	flush()
+	return lines
+}
+
+// splitWord breaks a word that is wider than width into pieces.
+func (l *pdfLayout) splitWord(w pdfWord, size, width float64) []pdfWord {
+	f := l.fonts[w.style]
+	var pieces []pdfWord
+	start := 0
+	var pw float64
+	for i, c := range w.text {
+		cw := f.width([]byte{c}, size)
+		if pw+cw > width && i > start {
+			pieces = append(pieces, pdfWord{text: w.text[start:i], style: w.style, link: w.link, width: pw})
+			start, pw = i, 0
+		}
+		pw += cw
+	}
+	last := w
+	last.text, last.width = w.text[start:], pw
+	return append(pieces, last)
+}
+
+func (l *pdfLayout) list(n *bf.Node, indent float64) {
+	ordered := n.ListFlags&bf.ListTypeOrdered != 0
+	tight := l.tight
+	l.tight = n.Tight
+
+	bullet := toWinAnsi("•")
+	if indent > 0 {
+		bullet = []byte("-")
+	}
+	counter := 1
+	for item := n.FirstChild; item != nil; item = item.Next {
+		marker := bullet
+		if ordered {
+			marker = []byte(strconv.Itoa(counter) + ".")
+			counter++
+		}
+		step := math.Max(l.size*1.8, l.font(fontRegular).width(marker, l.size)+l.size*0.6)
+		l.marker = &pdfMarker{text: marker, x: l.left + indent + l.size*0.4}
+		for c := item.FirstChild; c != nil; c = c.Next {
+			l.block(c, indent+step)
+		}
+		l.marker = nil
+	}
+
+	l.tight = tight
+	if !l.tight {
+		l.y += l.size * 0.4
+	}
+}
+
+func (l *pdfLayout) blockQuote(n *bf.Node, indent float64) {
+	startPage, startY := len(l.pages)-1, l.y
+	if startPage < 0 {
+		l.page()
+		startPage = 0
+	}
+	gray := l.gray
+	l.gray = true
+	for c := n.FirstChild; c != nil; c = c.Next {
+		l.block(c, indent+l.size*1.4)
+	}
+	l.gray = gray
+
+	// Draw the bar on every page the quote spans.
+	x := l.left + indent + l.size*0.4
+	for i := startPage; i < len(l.pages); i++ {
+		y0, y1 := l.top, l.bottom
+		if i == startPage {
+			y0 = startY
+		}
+		if i == len(l.pages)-1 {
+			y1 = l.y - l.size*0.6
+		}
+		if y1 <= y0 {
+			continue
+		}
+		fmt.Fprintf(&l.pages[i].ops, "2 w %s RG %s %s m %s %s l S\n",
+			colorRule, num(x), num(l.height-y0), num(x), num(l.height-y1))
+	}
+}
+
+func (l *pdfLayout) codeBlock(code string, indent float64) {
+	size := l.size * 0.9
+	lh := l.lineHeight(size)
+	pad := size * 0.6
+	x := l.left + indent
+	w := l.right - x
+	mono := l.font(fontMono)
+	charWidth := mono.width([]byte("m"), size)
+	perLine := int((w - 2*pad) / charWidth)
+	if perLine < 1 {
+		perLine = 1
+	}
+
+	var lines [][]byte
+	for _, line := range strings.Split(strings.TrimRight(code, "\n"), "\n") {
+		text := l.encode(line)
+		for len(text) > perLine {
+			lines = append(lines, text[:perLine])
+			text = text[perLine:]
+		}
+		lines = append(lines, text)
+	}
+
+	l.ensure(pad + lh)
+	l.fillRect(x, l.y, w, pad, colorShade)
+	l.y += pad
+	for _, line := range lines {
+		if l.y+lh > l.bottom {
+			l.newPage()
+		}
+		l.fillRect(x, l.y, w, lh, colorShade)
+		baseline := l.y + size*0.93
+		l.drawMarker(baseline, l.size)
+		l.text(x+pad, baseline, fontMono, size, line, colorText)
+		l.y += lh
+	}
+	l.fillRect(x, l.y, w, pad, colorShade)
+	l.y += pad + l.size*0.6
+}
+
+func (l *pdfLayout) table(n *bf.Node, indent float64) {
+	var header []pdfCell
+	var rows [][]pdfCell
+	cols := 0
+	for section := n.FirstChild; section != nil; section = section.Next {
+		for row := section.FirstChild; row != nil; row = row.Next {
+			var cells []pdfCell
+			for cell := row.FirstChild; cell != nil; cell = cell.Next {
+				style := fontRegular
+				if cell.IsHeader {
+					style = fontBold
+				}
+				cells = append(cells, pdfCell{
+					spans:  l.inlineSpans(cell, style, indent),
+					header: cell.IsHeader,
+					align:  cell.Align,
+				})
+			}
+			if len(cells) > cols {
+				cols = len(cells)
+			}
+			if section.Type == bf.TableHead {
+				header = cells
+			} else {
+				rows = append(rows, cells)
+			}
+		}
+	}
+	if cols == 0 {
+		return
+	}
+
+	size := l.size * 0.9
+	pad := size * 0.45
+	widths := l.columnWidths(header, rows, cols, size, pad, l.right-l.left-indent)
+
+	drawRow := func(cells []pdfCell, header bool) {
+		wrapped := make([][][]pdfWord, cols)
+		lines := 1
+		for i := 0; i < cols && i < len(cells); i++ {
+			wrapped[i] = l.wrap(cells[i].spans, size, widths[i]-2*pad)
+			if len(wrapped[i]) > lines {
+				lines = len(wrapped[i])
+			}
+		}
+		lh := l.lineHeight(size)
+		h := float64(lines)*lh + 2*pad
+
+		x := l.left + indent
+		for i, w := range widths {
+			if header {
+				l.fillRect(x, l.y, w, h, colorHead)
+			}
+			l.strokeRect(x, l.y, w, h, colorRule)
+			var align bf.CellAlignFlags
+			if i < len(cells) {
+				align = cells[i].align
+			}
+			for j, line := range wrapped[i] {
+				lx := x + pad
+				switch align {
+				case bf.TableAlignmentRight:
+					lx = x + w - pad - lineWidth(l, line, size)
+				case bf.TableAlignmentCenter:
+					lx = x + (w-lineWidth(l, line, size))/2
+				}
+				l.drawLine(line, lx, l.y+pad+float64(j)*lh+size*0.93, size)
+			}
+			x += w
+		}
+		l.y += h
+	}
+	rowHeight := func(cells []pdfCell) float64 {
+		lines := 1
+		for i := 0; i < cols && i < len(cells); i++ {
+			if n := len(l.wrap(cells[i].spans, size, widths[i]-2*pad)); n > lines {
+				lines = n
+			}
+		}
+		return float64(lines)*l.lineHeight(size) + 2*pad
+	}
+
+	first := true
+	if header != nil {
+		l.ensure(rowHeight(header) + l.lineHeight(size) + 2*pad)
+		drawRow(header, true)
+		first = false
+	}
+	for _, row := range rows {
+		h := rowHeight(row)
+		if l.y+h > l.bottom && !first {
+			// Repeat the header on every page the table continues on.
+			l.newPage()
+			if header != nil {
+				drawRow(header, true)
+			}
+		}
+		drawRow(row, false)
+		first = false
+	}
+	l.y += l.size * 0.8
+}
+
+// columnWidths sizes columns to their content, shrinking the widest ones
+// when the table does not fit in avail points.
+func (l *pdfLayout) columnWidths(header []pdfCell, rows [][]pdfCell, cols int, size, pad, avail float64) []float64 {
+	natural := make([]float64, cols)
+	measure := func(cells []pdfCell) {
+		for i := 0; i < cols && i < len(cells); i++ {
+			var w float64
+			for _, line := range l.wrap(cells[i].spans, size, math.Inf(1)) {
+				w = math.Max(w, lineWidth(l, line, size))
+			}
+			natural[i] = math.Max(natural[i], w+2*pad)
+		}
+	}
+	measure(header)
+	for _, row := range rows {
+		measure(row)
+	}
+
+	total := 0.0
+	for _, w := range natural {
+		total += w
+	}
+	if total <= avail {
+		return natural
+	}
+
+	// Columns narrower than an even share keep their width; the rest split
+	// the remaining space in proportion to their content.
+	fair := avail / float64(cols)
+	widths := make([]float64, cols)
+	rest, wide := avail, 0.0
+	for i, w := range natural {
+		if w <= fair {
+			widths[i] = w
+			rest -= w
+		} else {
+			wide += w
+		}
+	}
+	for i, w := range natural {
+		if w > fair {
+			widths[i] = rest * w / wide
+		}
+	}
+	return widths
+}
+
+func lineWidth(l *pdfLayout, line []pdfWord, size float64) float64 {
+	var w float64
+	for i, word := range line {
+		w += word.width
+		if word.space && i < len(line)-1 {
+			w += l.fonts[word.style].width([]byte(" "), size)
+		}
+	}
+	return w
+}
+
+func (l *pdfLayout) image(n *bf.Node, indent float64) {
+	dest := string(n.LinkData.Destination)
+	alt := strings.TrimSpace(textContent(n))
+
+	var img *pdfImage
+	var err error
+	if strings.Contains(dest, "://") {
+		err = fmt.Errorf("remote images are not supported")
+	} else {
+		img, err = l.loadImage(dest)
+	}
+	if err != nil {
+		if alt == "" {
+			alt = dest
+		}
+		l.spans([]pdfSpan{{text: "[image: " + alt + "]", style: fontItalic}}, indent, l.size, l.size*0.6)
+		return
+	}
+
+	// Images are placed at 96 dpi and scaled down to fit the content box.
+	w := float64(img.width) * 72 / 96
+	h := float64(img.height) * 72 / 96
+	maxW, maxH := l.right-l.left-indent, l.bottom-l.top
+	if scale := math.Min(maxW/w, maxH/h); scale < 1 {
+		w, h = w*scale, h*scale
+	}
+
+	l.ensure(h)
+	fmt.Fprintf(&l.page().ops, "q %s 0 0 %s %s %s cm /%s Do Q\n",
+		num(w), num(h), num(l.left+indent), num(l.height-l.y-h), img.ref)
+	l.y += h + l.size*0.6
+}
+
+func (l *pdfLayout) loadImage(dest string) (*pdfImage, error) {
+	path := dest
+	if !filepath.IsAbs(path) && l.baseDir != "" {
+		path = filepath.Join(l.baseDir, path)
+	}
+	if img, ok := l.images[path]; ok {
+		return img, nil
+	}
+	img, err := loadImage(path)
+	if err != nil {
+		return nil, err
+	}
+	img.ref = fmt.Sprintf("Im%d", len(l.imageList)+1)
+	l.images[path] = img
+	l.imageList = append(l.imageList, img)
+	return img, nil
+}
+
+// drawHeaderFooter adds the running header and footer to every page.
+func (l *pdfLayout) drawHeaderFooter() {
+	if l.g.NoHeaderFooter {
+		return
+	}
+	size := l.size * 0.8
+	for i, p := range l.pages {
+		var ops bytes.Buffer
+		if l.g.Header != "" {
+			y := l.height - l.top/2
+			fmt.Fprintf(&ops, "BT /F%d %s Tf %s rg %s %s Td %s Tj ET\n",
+				int(fontRegular)+1, num(size), colorGray, num(l.left), num(y), pdfString(l.encode(l.g.Header)))
+			fmt.Fprintf(&ops, "0.5 w %s RG %s %s m %s %s l S\n",
+				colorRule, num(l.left), num(y-size*0.5), num(l.right), num(y-size*0.5))
+		}
+		footer := strings.NewReplacer("{page}", strconv.Itoa(i+1), "{pages}", strconv.Itoa(len(l.pages))).Replace(l.g.Footer)
+		text := l.encode(footer)
+		x := (l.width - l.font(fontRegular).width(text, size)) / 2
+		fmt.Fprintf(&ops, "BT /F%d %s Tf %s rg %s %s Td %s Tj ET\n",
+			int(fontRegular)+1, num(size), colorGray, num(x), num(l.top/2-size/2), pdfString(text))
+		p.ops.Write(ops.Bytes())
+	}
+	l.used[fontRegular] = true
+}
+
+// build assembles the PDF file.
+func (l *pdfLayout) build() []byte {
+	l.page()
+	l.drawHeaderFooter()
+
+	w := &pdfWriter{}
+	w.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	catalog, pages, info := w.alloc(), w.alloc(), w.alloc()
+
+	var resources strings.Builder
+	resources.WriteString("<< /Font <<")
+	for style, f := range l.fonts {
+		if !l.used[style] {
+			continue
+		}
+		if f.objNum == 0 {
+			f.objNum = w.alloc()
+			w.writeFont(f)
+		}
+		fmt.Fprintf(&resources, " /F%d %d 0 R", style+1, f.objNum)
+	}
+	resources.WriteString(" >>")
+	if len(l.imageList) > 0 {
+		resources.WriteString(" /XObject <<")
+		for _, img := range l.imageList {
+			img.objNum = w.alloc()
+			w.writeImage(img)
+			fmt.Fprintf(&resources, " /%s %d 0 R", img.ref, img.objNum)
+		}
+		resources.WriteString(" >>")
+	}
+	resources.WriteString(" >>")
+
+	var kids []string
+	for _, p := range l.pages {
+		page, content := w.alloc(), w.alloc()
+		w.stream(content, "/Filter /FlateDecode", deflate(p.ops.Bytes()))
+
+		var annots []string
+		for _, link := range p.links {
+			a := w.alloc()
+			w.object(a, fmt.Sprintf("<< /Type /Annot /Subtype /Link /Rect [%s %s %s %s] /Border [0 0 0] /A << /S /URI /URI %s >> >>",
+				num(link.x0), num(link.y0), num(link.x1), num(link.y1), pdfString([]byte(link.uri))))
+			annots = append(annots, fmt.Sprintf("%d 0 R", a))
+		}
+		dict := fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R",
+			pages, num(l.width), num(l.height), resources.String(), content)
+		if len(annots) > 0 {
+			dict += " /Annots [" + strings.Join(annots, " ") + "]"
+		}
+		w.object(page, dict+" >>")
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+
+	w.object(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	w.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	w.object(info, fmt.Sprintf("<< /Title %s /Producer (Cronyx) /CreationDate (D:%s) >>",
+		pdfText(l.title), time.Now().UTC().Format("20060102150405Z")))
+	return w.finish(catalog, info)
+}
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+func stripTags(s string) string {
+	return tagPattern.ReplaceAllString(s, "")
+}
+
+// textContent returns the text of n's descendants.
+func textContent(n *bf.Node) string {
+	var b strings.Builder
+	n.Walk(func(c *bf.Node, entering bool) bf.WalkStatus {
+		if entering && (c.Type == bf.Text || c.Type == bf.Code) {
+			b.Write(c.Literal)
+		}
+		return bf.GoToNext
+	})
+	return b.String()
+}
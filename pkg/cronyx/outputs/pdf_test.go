@@ -0,0 +1,409 @@
+package outputs
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+type logRecorder []string
+
+func (l *logRecorder) Printf(format string, v ...interface{}) {
+	*l = append(*l, fmt.Sprintf(format, v...))
+}
+
+// pdfDoc is a PDF file read back through its cross-reference table.
+type pdfDoc struct {
+	objects map[int]string // object body by number
+	pages   []string       // inflated content stream of each page
+}
+
+var (
+	objRefRe  = regexp.MustCompile(`(\d+) 0 R`)
+	textOpRe  = regexp.MustCompile(`BT /F(\d+) ([\d.]+) Tf [\d. ]+ rg ([\d.-]+) ([\d.-]+) Td \(((?:\\.|[^\\)])*)\) Tj ET`)
+	contentRe = regexp.MustCompile(`/Contents (\d+) 0 R`)
+)
+
+// readPDF checks that every xref offset points at its object and returns
+// the objects and page contents.
+func readPDF(t *testing.T, data []byte) pdfDoc {
+	t.Helper()
+	i := bytes.LastIndex(data, []byte("startxref\n"))
+	if i < 0 || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("no startxref or EOF trailer")
+	}
+	xref, err := strconv.Atoi(strings.TrimSpace(string(data[i+len("startxref\n") : len(data)-len("%%EOF\n")])))
+	if err != nil || xref >= len(data) || !bytes.HasPrefix(data[xref:], []byte("xref\n0 ")) {
+		t.Fatalf("startxref %d doesn't point at the xref table", xref)
+	}
+	var size int
+	fmt.Sscanf(string(data[xref:]), "xref\n0 %d\n", &size)
+	entries := data[bytes.IndexByte(data[xref+5:], '\n')+xref+6:]
+	if !bytes.HasPrefix(entries, []byte("0000000000 65535 f \n")) {
+		t.Fatalf("first xref entry = %q", entries[:20])
+	}
+	if !bytes.Contains(data[xref:], []byte(fmt.Sprintf("/Size %d ", size))) {
+		t.Errorf("trailer /Size doesn't match the %d xref entries", size)
+	}
+
+	doc := pdfDoc{objects: map[int]string{}}
+	for n := 1; n < size; n++ {
+		entry := string(entries[20*n : 20*n+20])
+		off, err := strconv.Atoi(entry[:10])
+		if err != nil || !strings.HasSuffix(entry, " 00000 n \n") {
+			t.Fatalf("xref entry %d = %q", n, entry)
+		}
+		head := fmt.Sprintf("%d 0 obj\n", n)
+		if !bytes.HasPrefix(data[off:], []byte(head)) {
+			t.Fatalf("xref offset %d of object %d points at %q", off, n, data[off:off+20])
+		}
+		end := bytes.Index(data[off:], []byte("\nendobj\n"))
+		doc.objects[n] = string(data[off+len(head) : off+end])
+	}
+
+	root := doc.objects[ref(t, string(data[xref:]), "/Root")]
+	pages := doc.objects[ref(t, root, "/Pages")]
+	kids := pages[strings.Index(pages, "/Kids ["):]
+	kids = kids[:strings.Index(kids, "]")]
+	for _, m := range objRefRe.FindAllStringSubmatch(kids, -1) {
+		n, _ := strconv.Atoi(m[1])
+		c := contentRe.FindStringSubmatch(doc.objects[n])
+		if c == nil {
+			t.Fatalf("page %d has no contents", n)
+		}
+		cn, _ := strconv.Atoi(c[1])
+		doc.pages = append(doc.pages, string(inflateStream(t, doc.objects[cn])))
+	}
+	if !strings.Contains(pages, fmt.Sprintf("/Count %d ", len(doc.pages))) {
+		t.Errorf("pages object %q doesn't count %d pages", pages, len(doc.pages))
+	}
+	return doc
+}
+
+// ref returns the object number following key in dict.
+func ref(t *testing.T, dict, key string) int {
+	t.Helper()
+	i := strings.Index(dict, key+" ")
+	if i < 0 {
+		t.Fatalf("no %s in %q", key, dict)
+	}
+	var n int
+	fmt.Sscanf(dict[i+len(key)+1:], "%d 0 R", &n)
+	return n
+}
+
+// inflateStream returns the decompressed data of a Flate stream object.
+func inflateStream(t *testing.T, obj string) []byte {
+	t.Helper()
+	var length int
+	fmt.Sscanf(obj[strings.Index(obj, "/Length ")+len("/Length "):], "%d", &length)
+	start := strings.Index(obj, "stream\n") + len("stream\n")
+	if obj[start+length:] != "\nendstream" {
+		t.Fatalf("stream /Length %d doesn't match its data", length)
+	}
+	zr, err := zlib.NewReader(strings.NewReader(obj[start : start+length]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+type textOp struct {
+	font int
+	size float64
+	x, y float64
+	text string
+}
+
+func textOps(page string) []textOp {
+	var ops []textOp
+	for _, m := range textOpRe.FindAllStringSubmatch(page, -1) {
+		font, _ := strconv.Atoi(m[1])
+		size, _ := strconv.ParseFloat(m[2], 64)
+		x, _ := strconv.ParseFloat(m[3], 64)
+		y, _ := strconv.ParseFloat(m[4], 64)
+		ops = append(ops, textOp{font, size, x, y, m[5]})
+	}
+	return ops
+}
+
+// find returns the first text op drawing text.
+func find(t *testing.T, ops []textOp, text string) textOp {
+	t.Helper()
+	for _, op := range ops {
+		if op.text == text {
+			return op
+		}
+	}
+	t.Fatalf("no text op draws %q", text)
+	return textOp{}
+}
+
+func renderPDF(t *testing.T, g PDFOutputGenerator, markdown string) pdfDoc {
+	t.Helper()
+	data, err := g.Render(context.Background(), cronyx.RenderedDoc{Content: markdown})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return readPDF(t, data)
+}
+
+func TestPDFStructure(t *testing.T) {
+	doc := renderPDF(t, PDFOutputGenerator{Header: "Sales (Q1)"}, "# Title\n\nSome *text* with a [link](https://example.com).\n")
+	if len(doc.pages) != 1 {
+		t.Fatalf("%d pages, want 1", len(doc.pages))
+	}
+	ops := textOps(doc.pages[0])
+	if op := find(t, ops, "Title"); op.font != int(fontBold)+1 {
+		t.Errorf("heading uses font F%d, want bold", op.font)
+	}
+	find(t, ops, `Sales \(Q1\)`)
+	find(t, ops, "Page 1 of 1")
+	if op := find(t, ops, "text"); op.font != int(fontItalic)+1 {
+		t.Errorf("emphasis uses font F%d, want italic", op.font)
+	}
+
+	var annot bool
+	for _, obj := range doc.objects {
+		annot = annot || strings.Contains(obj, "/URI (https://example.com)")
+	}
+	if !annot {
+		t.Error("no link annotation")
+	}
+}
+
+func TestPDFPageBreaks(t *testing.T) {
+	var md strings.Builder
+	for i := 1; i <= 150; i++ {
+		fmt.Fprintf(&md, "Paragraph %d\n\n", i)
+	}
+	g := PDFOutputGenerator{PageSize: "A5"}
+	doc := renderPDF(t, g, md.String())
+	if len(doc.pages) < 3 {
+		t.Fatalf("%d pages, want the paragraphs spread over several", len(doc.pages))
+	}
+
+	seen := map[string]int{}
+	for i, page := range doc.pages {
+		ops := textOps(page)
+		find(t, ops, fmt.Sprintf("Page %d of %d", i+1, len(doc.pages)))
+		for _, op := range ops {
+			if !strings.HasPrefix(op.text, "Paragraph ") {
+				continue
+			}
+			seen[op.text]++
+			// text stays inside the margins of the A5 page
+			if op.y < 54 || op.y > pageSizes["a5"][1]-54 {
+				t.Errorf("page %d: %q drawn at y=%v, outside the margins", i+1, op.text, op.y)
+			}
+		}
+	}
+	for i := 1; i <= 150; i++ {
+		if n := seen[fmt.Sprintf("Paragraph %d", i)]; n != 1 {
+			t.Errorf("Paragraph %d drawn %d times", i, n)
+		}
+	}
+}
+
+func TestPDFTable(t *testing.T) {
+	var md strings.Builder
+	md.WriteString("| Name | Qty |\n|:-----|----:|\n")
+	for i := 1; i <= 80; i++ {
+		fmt.Fprintf(&md, "| item%d | %d |\n", i, i*i)
+	}
+	doc := renderPDF(t, PDFOutputGenerator{}, md.String())
+	if len(doc.pages) < 2 {
+		t.Fatalf("%d pages, want the table to continue on a second page", len(doc.pages))
+	}
+
+	for i, page := range doc.pages {
+		ops := textOps(page)
+		// the header row repeats on every page, shaded and in bold
+		name, qty := find(t, ops, "Name"), find(t, ops, "Qty")
+		if name.font != int(fontBold)+1 || !strings.Contains(page, colorHead+" rg") {
+			t.Errorf("page %d: header is not bold and shaded", i+1)
+		}
+		if name.y != qty.y || qty.x <= name.x {
+			t.Errorf("page %d: header cells at %v and %v", i+1, name, qty)
+		}
+	}
+
+	ops := textOps(doc.pages[0])
+	item1, item2, n1, n4 := find(t, ops, "item1"), find(t, ops, "item2"), find(t, ops, "1"), find(t, ops, "4")
+	name := find(t, ops, "Name")
+	if item1.x != name.x || item2.x != name.x {
+		t.Errorf("left aligned column: x = %v, %v, header %v", item1.x, item2.x, name.x)
+	}
+	if !(name.y > item1.y && item1.y > item2.y) || n1.y != item1.y || n4.y != item2.y {
+		t.Errorf("rows out of order: header y=%v, rows %v/%v and %v/%v", name.y, item1.y, n1.y, item2.y, n4.y)
+	}
+	// right alignment: single digits end where the header ends, so start further right
+	qty := find(t, ops, "Qty")
+	if n1.x <= qty.x {
+		t.Errorf("right aligned cell starts at %v, header at %v", n1.x, qty.x)
+	}
+	n100 := find(t, ops, "100")
+	if n100.x >= n1.x {
+		t.Errorf("wider number starts at %v, single digit at %v", n100.x, n1.x)
+	}
+}
+
+func TestPDFUnencodableText(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"winansi", "Café costs 5 € – “quoted”", ""},
+		{"outside winansi", "Snow ☃ in 世界, again ☃", `"☃世界"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs logRecorder
+			doc := renderPDF(t, PDFOutputGenerator{Logger: &logs}, tt.content)
+			if tt.want == "" {
+				if len(logs) != 0 {
+					t.Errorf("logged %q", logs)
+				}
+				find(t, textOps(doc.pages[0]), "Caf\xe9 costs 5 \x80 \x96 \x93quoted\x94")
+				return
+			}
+			if len(logs) != 1 || !strings.Contains(logs[0], tt.want) {
+				t.Errorf("logged %q, want one warning naming %s", logs, tt.want)
+			}
+		})
+	}
+}
+
+// testTTF builds a minimal TrueType font mapping ASCII to glyphs 1-95,
+// glyph g advancing 20*g units of a 2000 unit em.
+func testTTF(t *testing.T) string {
+	t.Helper()
+	u16 := func(b []byte, vs ...int) []byte {
+		for _, v := range vs {
+			b = binary.BigEndian.AppendUint16(b, uint16(v))
+		}
+		return b
+	}
+
+	head := make([]byte, 54)
+	binary.BigEndian.PutUint16(head[18:], 2000)
+	u16(head[36:36], -100, -400, 1800, 1600)
+	hhea := make([]byte, 36)
+	u16(hhea[4:4], 1600, -400)
+	binary.BigEndian.PutUint16(hhea[34:], 96)
+	var hmtx []byte
+	for g := 0; g < 96; g++ {
+		hmtx = u16(hmtx, 20*g, 0)
+	}
+	// format 4 subtable: 32-126 -> glyph c-31, plus the final 0xFFFF segment
+	sub := u16(nil, 4, 32, 0, 4, 4, 1, 0, 126, 0xFFFF, 0, 32, 0xFFFF, -31, 1, 0, 0)
+	cmap := append(u16(nil, 0, 1, 3, 1, 0, 12), sub...)
+
+	tables := []struct {
+		tag  string
+		data []byte
+	}{{"cmap", cmap}, {"head", head}, {"hhea", hhea}, {"hmtx", hmtx}}
+	font := u16([]byte("\x00\x01\x00\x00"), len(tables), 0, 0, 0)
+	off := 12 + 16*len(tables)
+	var body []byte
+	for _, tb := range tables {
+		font = append(font, tb.tag...)
+		font = binary.BigEndian.AppendUint32(font, 0)
+		font = binary.BigEndian.AppendUint32(font, uint32(off+len(body)))
+		font = binary.BigEndian.AppendUint32(font, uint32(len(tb.data)))
+		body = append(body, tb.data...)
+	}
+	font = append(font, body...)
+
+	path := filepath.Join(t.TempDir(), "Test Sans.ttf")
+	if err := os.WriteFile(path, font, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPDFTrueTypeFont(t *testing.T) {
+	path := testTTF(t)
+	f, err := loadTrueType(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 'A' is glyph 34: 680 units of 2000 -> 340
+	if f.base != "TestSans" || f.widths['A'] != 340 || f.widths[' '] != 10 || f.widths[0xE9] != 0 {
+		t.Errorf("font %s, widths A=%d space=%d é=%d", f.base, f.widths['A'], f.widths[' '], f.widths[0xE9])
+	}
+	if f.ascent != 800 || f.descent != -200 || f.bbox != [4]int{-50, -200, 900, 800} {
+		t.Errorf("ascent %d, descent %d, bbox %v", f.ascent, f.descent, f.bbox)
+	}
+
+	doc := renderPDF(t, PDFOutputGenerator{Fonts: PDFFonts{Regular: path}, NoHeaderFooter: true}, "**AB**")
+	var dict, desc string
+	for _, obj := range doc.objects {
+		switch {
+		case strings.Contains(obj, "/Subtype /TrueType"):
+			dict = obj
+		case strings.Contains(obj, "/Type /FontDescriptor"):
+			desc = obj
+		}
+	}
+	// bold falls back to the regular face, which is embedded once
+	if strings.Count(strings.Join(doc.pages, ""), "/F2 ") != 1 || dict == "" {
+		t.Fatalf("no embedded TrueType font dictionary")
+	}
+	if !strings.Contains(dict, "/BaseFont /TestSans /FirstChar 32 /LastChar 255 /Widths [10 20 ") {
+		t.Errorf("font dictionary = %q", dict)
+	}
+	program := inflateStream(t, doc.objects[ref(t, desc, "/FontFile2")])
+	want, _ := os.ReadFile(path)
+	if !bytes.Equal(program, want) {
+		t.Error("embedded font program differs from the file")
+	}
+}
+
+func TestLoadTrueTypeErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, data, 0644)
+		return path
+	}
+	good, _ := os.ReadFile(testTTF(t))
+	noCmap := append([]byte(nil), good...)
+	copy(noCmap[12:], "xxxx") // rename the cmap table
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"cff", append([]byte("OTTO"), good[4:]...), "OpenType CFF"},
+		{"short", []byte("true"), "not a TrueType font"},
+		{"missing table", noCmap, "missing cmap table"},
+		{"truncated directory", good[:20], "truncated table directory"},
+		{"table out of bounds", good[:80], "out of bounds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadTrueType(write(tt.name+".ttf", tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package outputs
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fontStyle selects one of the document fonts.
+type fontStyle int
+
+const (
+	fontRegular fontStyle = iota
+	fontBold
+	fontItalic
+	fontBoldItalic
+	fontMono
+)
+
+// PDFFonts lists TrueType (.ttf) files to embed. Empty entries fall back to
+// a related face (BoldItalic to Bold, Italic to Regular) or, for Regular
+// and Mono, to the built-in Helvetica and Courier fonts.
+type PDFFonts struct {
+	Regular    string
+	Bold       string
+	Italic     string
+	BoldItalic string
+	Mono       string
+}
+
+// pdfFont holds the metrics needed to lay out WinAnsi-encoded text.
+type pdfFont struct {
+	base    string   // PostScript name used as BaseFont
+	widths  [256]int // glyph advances in 1/1000 em, indexed by WinAnsi code
+	ascent  int      // in 1/1000 em
+	descent int      // in 1/1000 em, negative
+	bbox    [4]int   // font bounding box in 1/1000 em
+	program []byte   // TrueType program to embed; nil for built-in fonts
+	ref     string   // resource name, e.g. "F1"
+	objNum  int      // font dictionary object, assigned when writing
+}
+
+// width returns the width of WinAnsi text at size points.
+func (f *pdfFont) width(text []byte, size float64) float64 {
+	w := 0
+	for _, c := range text {
+		w += f.widths[c]
+	}
+	return float64(w) * size / 1000
+}
+
+// Widths of the printable ASCII range (32-126) in the standard fonts, from
+// the Adobe Core 14 AFM files.
+var (
+	helveticaWidths = []int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = []int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+// standardFont returns the metrics of a built-in font. Codes outside
+// printable ASCII get approximate widths.
+func standardFont(style fontStyle) *pdfFont {
+	f := &pdfFont{ascent: 718, descent: -207, bbox: [4]int{-166, -225, 1000, 931}}
+	var ascii []int
+	switch style {
+	case fontBold:
+		f.base, ascii = "Helvetica-Bold", helveticaBoldWidths
+	case fontItalic:
+		f.base, ascii = "Helvetica-Oblique", helveticaWidths
+	case fontBoldItalic:
+		f.base, ascii = "Helvetica-BoldOblique", helveticaBoldWidths
+	case fontMono:
+		f.base = "Courier"
+		f.ascent, f.descent = 629, -157
+		f.bbox = [4]int{-23, -250, 715, 805}
+		for i := range f.widths {
+			f.widths[i] = 600
+		}
+		return f
+	default:
+		f.base, ascii = "Helvetica", helveticaWidths
+	}
+
+	for i := range f.widths {
+		f.widths[i] = 556
+	}
+	copy(f.widths[32:], ascii)
+	for code, w := range map[byte]int{0x85: 1000, 0x91: 222, 0x92: 222, 0x93: 333, 0x94: 333, 0x95: 350, 0x96: 556, 0x97: 1000, 0xA0: 278} {
+		f.widths[code] = w
+	}
+	return f
+}
+
+// loadTrueType reads a .ttf file and computes WinAnsi widths from its
+// cmap and hmtx tables.
+func loadTrueType(path string) (*pdfFont, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := ttfTables(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, tag := range []string{"head", "hhea", "hmtx", "cmap"} {
+		if tables[tag] == nil {
+			return nil, fmt.Errorf("%s: missing %s table", path, tag)
+		}
+	}
+
+	head, hhea := tables["head"], tables["hhea"]
+	if len(head) < 54 || len(hhea) < 36 {
+		return nil, fmt.Errorf("%s: truncated head or hhea table", path)
+	}
+	unitsPerEm := int(binary.BigEndian.Uint16(head[18:]))
+	if unitsPerEm == 0 {
+		return nil, fmt.Errorf("%s: invalid unitsPerEm", path)
+	}
+	scale := func(v int) int { return v * 1000 / unitsPerEm }
+
+	f := &pdfFont{
+		base:    fontName(path),
+		ascent:  scale(int(int16(binary.BigEndian.Uint16(hhea[4:])))),
+		descent: scale(int(int16(binary.BigEndian.Uint16(hhea[6:])))),
+		program: data,
+	}
+	for i := range f.bbox {
+		f.bbox[i] = scale(int(int16(binary.BigEndian.Uint16(head[36+2*i:]))))
+	}
+
+	numHMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := tables["hmtx"]
+	advance := func(glyph int) int {
+		if numHMetrics == 0 {
+			return 0
+		}
+		if glyph >= numHMetrics {
+			glyph = numHMetrics - 1
+		}
+		if 4*glyph+2 > len(hmtx) {
+			return 0
+		}
+		return int(binary.BigEndian.Uint16(hmtx[4*glyph:]))
+	}
+
+	cmap, err := ttfCmap(tables["cmap"])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for code := 32; code < 256; code++ {
+		r := winAnsiRune(byte(code))
+		f.widths[code] = scale(advance(cmap(r)))
+	}
+	return f, nil
+}
+
+// ttfTables indexes the table directory of a TrueType file.
+func ttfTables(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("not a TrueType font")
+	}
+	switch string(data[:4]) {
+	case "\x00\x01\x00\x00", "true":
+	default:
+		return nil, fmt.Errorf("not a TrueType font (OpenType CFF fonts are not supported)")
+	}
+	n := int(binary.BigEndian.Uint16(data[4:]))
+	tables := map[string][]byte{}
+	for i := 0; i < n; i++ {
+		rec := 12 + 16*i
+		if rec+16 > len(data) {
+			return nil, fmt.Errorf("truncated table directory")
+		}
+		off := int(binary.BigEndian.Uint32(data[rec+8:]))
+		length := int(binary.BigEndian.Uint32(data[rec+12:]))
+		if off+length > len(data) {
+			return nil, fmt.Errorf("table %q out of bounds", data[rec:rec+4])
+		}
+		tables[string(data[rec:rec+4])] = data[off : off+length]
+	}
+	return tables, nil
+}
+
+// ttfCmap returns a rune to glyph lookup from a format 4 Unicode subtable.
+func ttfCmap(cmap []byte) (func(rune) int, error) {
+	if len(cmap) < 4 {
+		return nil, fmt.Errorf("truncated cmap table")
+	}
+	n := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < n; i++ {
+		rec := 4 + 8*i
+		if rec+8 > len(cmap) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(cmap[rec:])
+		encoding := binary.BigEndian.Uint16(cmap[rec+2:])
+		off := int(binary.BigEndian.Uint32(cmap[rec+4:]))
+		if !(platform == 3 && encoding == 1) && platform != 0 {
+			continue
+		}
+		if off+14 > len(cmap) || binary.BigEndian.Uint16(cmap[off:]) != 4 {
+			continue
+		}
+		return cmapFormat4(cmap[off:]), nil
+	}
+	return nil, fmt.Errorf("no Unicode format 4 cmap subtable")
+}
+
+func cmapFormat4(sub []byte) func(rune) int {
+	u16 := func(off int) int {
+		if off < 0 || off+2 > len(sub) {
+			return 0
+		}
+		return int(binary.BigEndian.Uint16(sub[off:]))
+	}
+	segCount := u16(6) / 2
+	endCodes := 14
+	startCodes := endCodes + 2*segCount + 2
+	idDeltas := startCodes + 2*segCount
+	idRangeOffsets := idDeltas + 2*segCount
+
+	return func(r rune) int {
+		c := int(r)
+		for i := 0; i < segCount; i++ {
+			if c > u16(endCodes+2*i) {
+				continue
+			}
+			start := u16(startCodes + 2*i)
+			if c < start {
+				return 0
+			}
+			delta := u16(idDeltas + 2*i)
+			rangeOff := u16(idRangeOffsets + 2*i)
+			if rangeOff == 0 {
+				return (c + delta) & 0xFFFF
+			}
+			glyph := u16(idRangeOffsets + 2*i + rangeOff + 2*(c-start))
+			if glyph == 0 {
+				return 0
+			}
+			return (glyph + delta) & 0xFFFF
+		}
+		return 0
+	}
+}
+
+// fontName derives a PostScript-safe font name from a file name.
+func fontName(path string) string {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return strings.Map(func(r rune) rune {
+		if r > 32 && r < 127 && !strings.ContainsRune("[](){}<>/%#", r) {
+			return r
+		}
+		return -1
+	}, base)
+}
+
+// winAnsiExtras maps the 0x80-0x9F range of WinAnsiEncoding (cp1252).
+var winAnsiExtras = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+	0x88: 'ˆ', 0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž', 0x91: '‘',
+	0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—', 0x98: '˜',
+	0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+}
+
+var winAnsiCodes = func() map[rune]byte {
+	m := map[rune]byte{}
+	for b, r := range winAnsiExtras {
+		m[r] = b
+	}
+	return m
+}()
+
+func winAnsiRune(b byte) rune {
+	if r, ok := winAnsiExtras[b]; ok {
+		return r
+	}
+	return rune(b)
+}
+
+// toWinAnsi encodes s for the WinAnsi-encoded fonts. Characters outside the
+// encoding become '?'.
+func toWinAnsi(s string) []byte {
+	return encodeWinAnsi(s, nil)
+}
+
+// encodeWinAnsi is toWinAnsi, calling missing (when not nil) for every
+// character replaced by '?'.
+func encodeWinAnsi(s string, missing func(rune)) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			out = append(out, ' ', ' ', ' ', ' ')
+		case r < 32:
+		case r < 128 || (r >= 0xA0 && r <= 0xFF):
+			out = append(out, byte(r))
+		default:
+			if b, ok := winAnsiCodes[r]; ok {
+				out = append(out, b)
+			} else {
+				out = append(out, '?')
+				if missing != nil {
+					missing(r)
+				}
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,171 @@
+package outputs
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// pdfWriter serialises numbered objects and the cross-reference table.
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets []int // byte offset of each object, index 0 is object 1
+}
+
+// alloc reserves an object number.
+func (w *pdfWriter) alloc() int {
+	w.offsets = append(w.offsets, 0)
+	return len(w.offsets)
+}
+
+func (w *pdfWriter) object(n int, dict string) {
+	w.offsets[n-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", n, dict)
+}
+
+// stream writes a stream object. dict holds the entries besides /Length.
+func (w *pdfWriter) stream(n int, dict string, data []byte) {
+	w.offsets[n-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< %s /Length %d >>\nstream\n", n, dict, len(data))
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+// finish writes the xref table and trailer and returns the file.
+func (w *pdfWriter) finish(root, info int) []byte {
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, off := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(w.offsets)+1, root, info, xref)
+	return w.buf.Bytes()
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
+
+// pdfString returns s as a PDF literal string.
+func pdfString(s []byte) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, c := range s {
+		switch c {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\n':
+			b.WriteString(`\n`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// pdfText encodes UTF-8 text for the document information dictionary.
+func pdfText(s string) string {
+	return pdfString(toWinAnsi(s))
+}
+
+// num formats a coordinate with at most two decimals.
+func num(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
+
+// writeFont writes the font dictionary, plus descriptor and font program
+// for embedded fonts.
+func (w *pdfWriter) writeFont(f *pdfFont) {
+	if f.program == nil {
+		w.object(f.objNum, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.base))
+		return
+	}
+
+	file, desc := w.alloc(), w.alloc()
+	w.stream(file, fmt.Sprintf("/Filter /FlateDecode /Length1 %d", len(f.program)), deflate(f.program))
+	w.object(desc, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] "+
+		"/ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		f.base, f.bbox[0], f.bbox[1], f.bbox[2], f.bbox[3], f.ascent, f.descent, f.ascent, file))
+
+	widths := make([]string, 0, 224)
+	for code := 32; code < 256; code++ {
+		widths = append(widths, strconv.Itoa(f.widths[code]))
+	}
+	w.object(f.objNum, fmt.Sprintf("<< /Type /Font /Subtype /TrueType /BaseFont /%s /FirstChar 32 /LastChar 255 "+
+		"/Widths [%s] /Encoding /WinAnsiEncoding /FontDescriptor %d 0 R >>",
+		f.base, strings.Join(widths, " "), desc))
+}
+
+// pdfImage is an image XObject ready to be written.
+type pdfImage struct {
+	ref           string // resource name, e.g. "Im1"
+	width, height int
+	colorSpace    string
+	filter        string
+	data          []byte
+	objNum        int
+}
+
+// loadImage reads a JPEG, PNG or GIF file. JPEGs are embedded as-is, other
+// formats are decoded and stored as Flate-compressed RGB over white.
+func loadImage(path string) (*pdfImage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image %s: %w", path, err)
+	}
+	if format == "jpeg" {
+		cs := "/DeviceRGB"
+		switch cfg.ColorModel {
+		case color.GrayModel:
+			cs = "/DeviceGray"
+		case color.CMYKModel:
+			// Adobe CMYK JPEGs store inverted values
+			cs = "/DeviceCMYK /Decode [1 0 1 0 1 0 1 0]"
+		}
+		return &pdfImage{width: cfg.Width, height: cfg.Height, colorSpace: cs, filter: "/DCTDecode", data: data}, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image %s: %w", path, err)
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(b)
+	draw.Draw(rgba, b, image.White, image.Point{}, draw.Src)
+	draw.Draw(rgba, b, img, b.Min, draw.Over)
+
+	rgb := make([]byte, 0, b.Dx()*b.Dy()*3)
+	for i := 0; i < len(rgba.Pix); i += 4 {
+		rgb = append(rgb, rgba.Pix[i], rgba.Pix[i+1], rgba.Pix[i+2])
+	}
+	return &pdfImage{width: b.Dx(), height: b.Dy(), colorSpace: "/DeviceRGB", filter: "/FlateDecode", data: deflate(rgb)}, nil
+}
+
+func (w *pdfWriter) writeImage(img *pdfImage) {
+	w.stream(img.objNum, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter %s",
+		img.width, img.height, img.colorSpace, img.filter), img.data)
+}
@@ -0,0 +1,236 @@
+package outputs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+// DefaultFileName is the file name template used when a generator has none.
+const DefaultFileName = `report_{{.Date "20060102_150405"}}.{{.Format}}`
+
+// maxCollisions bounds the numbered suffixes tried for a taken file name.
+const maxCollisions = 1000
+
+// linkFile claims a file name for writeAtomic; a variable so tests can
+// simulate file systems without hard links.
+var linkFile = os.Link
+
+// NameData is the data available to file name templates, e.g.
+//
+//	{{.JobID}}/{{.Date "2006/01/02"}}/{{.Slug}}_{{.RunID}}.{{.Format}}
+type NameData struct {
+	JobID   string
+	JobName string
+	Slug    string // JobName (or JobID) lowercased with runs of other characters replaced by "-"
+	RunID   string
+	Labels  map[string]string
+	Format  string
+	Time    time.Time // run start, or now outside the engine
+}
+
+// Date formats the run time with a Go time layout.
+func (d NameData) Date(layout string) string {
+	return d.Time.Format(layout)
+}
+
+// NewNameData collects the naming data for a file of the given format
+// from the run attached to ctx.
+func NewNameData(ctx context.Context, format string) NameData {
+	d := NameData{Format: format, Time: time.Now()}
+	if info, ok := cronyx.RunInfoFromContext(ctx); ok {
+		d.JobID = info.Job.ID
+		d.JobName = info.Job.Name
+		d.RunID = info.RunID
+		d.Labels = info.Job.Labels
+		if !info.StartedAt.IsZero() {
+			d.Time = info.StartedAt
+		}
+	}
+	d.Slug = Slugify(valueOr(d.JobName, d.JobID))
+	return d
+}
+
+// ExpandName executes a file name template. The result is a clean,
+// relative, slash-separated path; templates that escape their base
+// directory or expand to nothing are rejected.
+func ExpandName(tpl string, data NameData) (string, error) {
+	t, err := template.New("name").Option("missingkey=zero").Funcs(template.FuncMap{
+		"slug":  Slugify,
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+	}).Parse(tpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid file name template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to expand file name template: %w", err)
+	}
+
+	name := strings.TrimSpace(buf.String())
+	if filepath.IsAbs(name) || strings.HasPrefix(filepath.ToSlash(name), "/") {
+		return "", fmt.Errorf("file name %q must be relative", name)
+	}
+	var parts []string
+	for _, part := range strings.Split(filepath.ToSlash(name), "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			return "", fmt.Errorf("file name %q leaves the output directory", name)
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("file name template %q expanded to an empty name", tpl)
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+// Slugify lowercases s and replaces every run of characters other than
+// letters and digits with a single "-".
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// writeOutput writes data to dir under the name expanded from nameTpl
+// (DefaultFileName when empty). The file is written to a temporary file
+// and moved into place, so readers never see partial output, and an
+// existing file is never replaced: a numbered suffix is added instead.
+func writeOutput(ctx context.Context, dir, nameTpl, format string, data []byte) (cronyx.OutputFile, error) {
+	// don't publish output for a cancelled run
+	if err := ctx.Err(); err != nil {
+		return cronyx.OutputFile{}, err
+	}
+	name, err := ExpandName(valueOr(nameTpl, DefaultFileName), NewNameData(ctx, format))
+	if err != nil {
+		return cronyx.OutputFile{}, err
+	}
+	outPath := filepath.Join(dir, filepath.FromSlash(name))
+
+	// Create output directory if it doesn't exist
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return cronyx.OutputFile{}, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	outPath, err = writeAtomic(outPath, data)
+	if err != nil {
+		return cronyx.OutputFile{}, fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	return cronyx.OutputFile{
+		Name: filepath.Base(outPath),
+		Path: outPath,
+		Data: data,
+	}, nil
+}
+
+// writeAtomic writes data next to path and publishes it under path, or
+// under path with a "_1", "_2", ... suffix when that name is taken. It
+// returns the path used.
+//
+// The name is claimed with a hard link, which fails rather than replacing
+// an existing file. On file systems without hard links the name is first
+// reserved by creating an empty file with O_EXCL and the data is then
+// renamed over it. Readers can briefly see that empty file there, and a
+// file put in its place between the two steps would be replaced, so the
+// no-clobber guarantee only holds against writers that also use O_EXCL.
+func writeAtomic(path string, data []byte) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return "", err
+	}
+
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	for i := 0; i < maxCollisions; i++ {
+		target := path
+		if i > 0 {
+			target = fmt.Sprintf("%s_%d%s", stem, i, ext)
+		}
+
+		err := linkFile(tmp.Name(), target)
+		if err == nil {
+			return target, nil
+		}
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+
+		// no hard links: reserve the name, then move the data over it
+		f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		f.Close()
+		if err := os.Rename(tmp.Name(), target); err != nil {
+			os.Remove(target)
+			return "", err
+		}
+		return target, nil
+	}
+	return "", fmt.Errorf("%s and %d numbered variants already exist", path, maxCollisions-1)
+}
+
+// columnOrder returns the columns to export: cols when the loader provided
+// them, otherwise every key found in rows, sorted.
+func columnOrder(cols []string, rows []map[string]interface{}) []string {
+	if len(cols) > 0 {
+		return cols
+	}
+	seen := map[string]bool{}
+	for _, row := range rows {
+		for k := range row {
+			if !seen[k] {
+				seen[k] = true
+				cols = append(cols, k)
+			}
+		}
+	}
+	sort.Strings(cols)
+	return cols
+}
@@ -0,0 +1,164 @@
+package outputs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+func runContext() context.Context {
+	return cronyx.ContextWithRunInfo(context.Background(), cronyx.RunInfo{
+		RunID:     "run-1",
+		Job:       cronyx.ReportJob{ID: "daily", Name: "Daily Sales (EU)", Labels: map[string]string{"team": "ops"}},
+		StartedAt: time.Date(2025, 3, 1, 8, 0, 5, 0, time.UTC),
+	})
+}
+
+func TestWriteOutputDefaultName(t *testing.T) {
+	dir := t.TempDir()
+	f, err := writeOutput(runContext(), dir, "", "csv", []byte("a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name != "report_20250301_080005.csv" || f.Path != filepath.Join(dir, f.Name) || string(f.Data) != "a\n" {
+		t.Errorf("file = %+v, want report_20250301_080005.csv in %s", f, dir)
+	}
+
+	// outside a run the current time is used
+	f, err = writeOutput(context.Background(), dir, "", "pdf", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^report_\d{8}_\d{6}\.pdf$`).MatchString(f.Name) {
+		t.Errorf("Name = %s, want report_<date>_<time>.pdf", f.Name)
+	}
+}
+
+func TestWriteOutputCollisions(t *testing.T) {
+	for _, links := range []bool{true, false} {
+		name := "hard links"
+		if !links {
+			name = "no hard links"
+		}
+		t.Run(name, func(t *testing.T) {
+			if !links {
+				linkFile = func(oldname, newname string) error {
+					return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: errors.New("not supported")}
+				}
+				t.Cleanup(func() { linkFile = os.Link })
+			}
+			dir := t.TempDir()
+			write := func(data string) string {
+				f, err := writeOutput(runContext(), dir, "{{.JobID}}/report.txt", "txt", []byte(data))
+				if err != nil {
+					t.Error(err)
+					return ""
+				}
+				return f.Path
+			}
+
+			for i, want := range []string{"report.txt", "report_1.txt", "report_2.txt"} {
+				data := strings.Repeat("x", i+1)
+				path := write(data)
+				if path != filepath.Join(dir, "daily", want) {
+					t.Errorf("write %d went to %s, want %s", i+1, path, want)
+				}
+				if b, _ := os.ReadFile(path); string(b) != data {
+					t.Errorf("%s = %q, want %q", want, b, data)
+				}
+			}
+
+			// concurrent writers never share a name
+			var wg sync.WaitGroup
+			paths := make([]string, 8)
+			for i := range paths {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					paths[i] = write("concurrent")
+				}()
+			}
+			wg.Wait()
+			sort.Strings(paths)
+			for i := 1; i < len(paths); i++ {
+				if paths[i] == paths[i-1] {
+					t.Errorf("two writers got %s", paths[i])
+				}
+			}
+
+			entries, _ := os.ReadDir(filepath.Join(dir, "daily"))
+			if len(entries) != 3+len(paths) {
+				var names []string
+				for _, e := range entries {
+					names = append(names, e.Name())
+				}
+				t.Errorf("directory holds %q, want %d reports and no temporary files", names, 3+len(paths))
+			}
+		})
+	}
+}
+
+func TestWriteOutputCancelled(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := writeOutput(ctx, dir, "", "txt", []byte("x")); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("cancelled write left %d files", len(entries))
+	}
+}
+
+func TestExpandName(t *testing.T) {
+	data := NewNameData(runContext(), "xlsx")
+	tests := []struct {
+		tpl     string
+		want    string
+		wantErr string
+	}{
+		{tpl: DefaultFileName, want: "report_20250301_080005.xlsx"},
+		{tpl: `{{.JobID}}/{{.Date "2006/01/02"}}/{{.Slug}}_{{.RunID}}.{{.Format}}`, want: "daily/2025/03/01/daily-sales-eu_run-1.xlsx"},
+		{tpl: `{{.Labels.team | upper}}/{{.Labels.missing}}/{{slug .JobName}}.txt`, want: "OPS/daily-sales-eu.txt"},
+		{tpl: " ./a//b/./c.txt ", want: "a/b/c.txt"},
+		{tpl: "/etc/passwd", wantErr: "must be relative"},
+		{tpl: "../{{.JobID}}.txt", wantErr: "leaves the output directory"},
+		{tpl: "{{.Labels.missing}}", wantErr: "empty name"},
+		{tpl: "{{.Nope}}", wantErr: "failed to expand"},
+		{tpl: "{{.JobID", wantErr: "invalid file name template"},
+	}
+	for _, tt := range tests {
+		got, err := ExpandName(tt.tpl, data)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ExpandName(%q) = %q, %v; want error %q", tt.tpl, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ExpandName(%q) = %q, %v; want %q", tt.tpl, got, err, tt.want)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	for in, want := range map[string]string{
+		"Daily Sales (EU)": "daily-sales-eu",
+		"--weekly--":       "weekly",
+		"Q1/Q2 Übersicht":  "q1-q2-übersicht",
+		"":                 "",
+	} {
+		if got := Slugify(in); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package outputs
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+// Cell style indexes into the cellXfs table written by xlsxStyles.
+const (
+	styleDefault = iota
+	styleHeader
+	styleDate
+	styleDateTime
+	styleFloat
+)
+
+const (
+	minColumnWidth = 8
+	maxColumnWidth = 60
+)
+
+// XLSXOutputGenerator writes the loaded rows as an Office Open XML
+// workbook. The main rows go to the first sheet and every extra data set
+// (DataPayload.Sets) to a sheet of its own. Each sheet has a styled,
+// frozen header row, typed cells and column widths sized to the content.
+// Text cells share one string table across the workbook.
+type XLSXOutputGenerator struct {
+	OutDir string
+	// FileName is the file name template (default DefaultFileName).
+	FileName string
+	// SheetName names the sheet holding DataPayload.Rows (default "Data").
+	SheetName string
+	// DateFormat and DateTimeFormat are Excel number formats for time
+	// values (defaults "yyyy-mm-dd" and "yyyy-mm-dd hh:mm:ss").
+	DateFormat     string
+	DateTimeFormat string
+	// FloatFormat is the Excel number format for floats (default "#,##0.00").
+	FloatFormat string
+}
+
+type xlsxSheet struct {
+	name    string
+	columns []string
+	rows    []map[string]interface{}
+}
+
+// sharedStrings is the workbook's string table; cells refer to its entries
+// by index.
+type sharedStrings struct {
+	index map[string]int
+	list  []string
+	refs  int // string cells, the table's count attribute
+}
+
+// add returns the index of s, adding it to the table when new.
+func (sst *sharedStrings) add(s string) int {
+	sst.refs++
+	if i, ok := sst.index[s]; ok {
+		return i
+	}
+	if sst.index == nil {
+		sst.index = map[string]int{}
+	}
+	sst.index[s] = len(sst.list)
+	sst.list = append(sst.list, s)
+	return len(sst.list) - 1
+}
+
+// xml renders sharedStrings.xml.
+func (sst *sharedStrings) xml() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	fmt.Fprintf(&b, `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" count="%d" uniqueCount="%d">`, sst.refs, len(sst.list))
+	for _, s := range sst.list {
+		fmt.Fprintf(&b, `<si><t xml:space="preserve">%s</t></si>`, xmlEscape(s))
+	}
+	b.WriteString(`</sst>`)
+	return b.String()
+}
+
+func (g XLSXOutputGenerator) Generate(ctx context.Context, r cronyx.RenderedDoc, format string) (cronyx.OutputFile, error) {
+	sheets := []xlsxSheet{{
+		name:    valueOr(g.SheetName, "Data"),
+		columns: columnOrder(r.Data.Columns, r.Data.Rows),
+		rows:    r.Data.Rows,
+	}}
+	for _, set := range r.Data.Sets {
+		sheets = append(sheets, xlsxSheet{
+			name:    set.Name,
+			columns: columnOrder(set.Columns, set.Rows),
+			rows:    set.Rows,
+		})
+	}
+
+	data, err := g.workbook(ctx, sheets)
+	if err != nil {
+		return cronyx.OutputFile{}, fmt.Errorf("failed to build xlsx: %w", err)
+	}
+	return writeOutput(ctx, g.OutDir, g.FileName, "xlsx", data)
+}
+
+// workbook builds the zipped package.
+func (g XLSXOutputGenerator) workbook(ctx context.Context, sheets []xlsxSheet) ([]byte, error) {
+	names := sheetNames(sheets)
+
+	var sst sharedStrings
+	sheetXML := make([]string, len(sheets))
+	for i, sheet := range sheets {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		sheetXML[i] = worksheet(sheet, &sst)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	add := func(name, content string) error {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write([]byte(content))
+		return err
+	}
+
+	var overrides, sheetEntries, rels strings.Builder
+	for i, name := range names {
+		n := i + 1
+		fmt.Fprintf(&overrides, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&sheetEntries, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(name), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(names)+1)
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings" Target="sharedStrings.xml"/>`, len(names)+2)
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			`<Override PartName="/xl/sharedStrings.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sharedStrings+xml"/>` +
+			overrides.String() + `</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + sheetEntries.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels.String() + `</Relationships>`},
+		{"xl/styles.xml", g.styles()},
+		{"xl/sharedStrings.xml", sst.xml()},
+	}
+	for _, p := range parts {
+		if err := add(p.name, p.content); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, content := range sheetXML {
+		if err := add(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// styles returns styles.xml. The cellXfs order must match the style* constants.
+func (g XLSXOutputGenerator) styles() string {
+	return xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<numFmts count="3">` +
+		`<numFmt numFmtId="164" formatCode="` + xmlEscape(valueOr(g.DateFormat, "yyyy-mm-dd")) + `"/>` +
+		`<numFmt numFmtId="165" formatCode="` + xmlEscape(valueOr(g.DateTimeFormat, "yyyy-mm-dd hh:mm:ss")) + `"/>` +
+		`<numFmt numFmtId="166" formatCode="` + xmlEscape(valueOr(g.FloatFormat, "#,##0.00")) + `"/>` +
+		`</numFmts>` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="3"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill>` +
+		`<fill><patternFill patternType="solid"><fgColor rgb="FFD9E1F2"/><bgColor indexed="64"/></patternFill></fill></fills>` +
+		`<borders count="2"><border><left/><right/><top/><bottom/><diagonal/></border>` +
+		`<border><left/><right/><top/><bottom style="thin"><color auto="1"/></bottom><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="5">` +
+		`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="2" borderId="1" xfId="0" applyFont="1" applyFill="1" applyBorder="1"/>` +
+		`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`<xf numFmtId="166" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`</cellXfs>` +
+		`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+		`</styleSheet>`
+}
+
+// worksheet renders one sheet's XML, adding its text to sst.
+func worksheet(s xlsxSheet, sst *sharedStrings) string {
+	widths := make([]int, len(s.columns))
+	for i, col := range s.columns {
+		widths[i] = utf8.RuneCountInString(col)
+	}
+
+	var data strings.Builder
+	data.WriteString(`<row r="1">`)
+	for i, col := range s.columns {
+		fmt.Fprintf(&data, `<c r="%s1" t="s" s="%d"><v>%d</v></c>`, cellRef(i), styleHeader, sst.add(col))
+	}
+	data.WriteString(`</row>`)
+
+	for r, row := range s.rows {
+		rowNum := r + 2
+		fmt.Fprintf(&data, `<row r="%d">`, rowNum)
+		for i, col := range s.columns {
+			cell, width := xlsxCell(fmt.Sprintf("%s%d", cellRef(i), rowNum), row[col], sst)
+			data.WriteString(cell)
+			if width > widths[i] {
+				widths[i] = width
+			}
+		}
+		data.WriteString(`</row>`)
+	}
+
+	var out strings.Builder
+	out.WriteString(xml.Header)
+	out.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if len(s.columns) > 0 {
+		fmt.Fprintf(&out, `<dimension ref="A1:%s%d"/>`, cellRef(len(s.columns)-1), len(s.rows)+1)
+	}
+	out.WriteString(`<sheetViews><sheetView workbookViewId="0">` +
+		`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>` +
+		`<selection pane="bottomLeft" activeCell="A2" sqref="A2"/>` +
+		`</sheetView></sheetViews>`)
+	if len(s.columns) > 0 {
+		out.WriteString(`<cols>`)
+		for i, w := range widths {
+			w += 2
+			if w < minColumnWidth {
+				w = minColumnWidth
+			}
+			if w > maxColumnWidth {
+				w = maxColumnWidth
+			}
+			fmt.Fprintf(&out, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, w)
+		}
+		out.WriteString(`</cols>`)
+	}
+	out.WriteString(`<sheetData>`)
+	out.WriteString(data.String())
+	out.WriteString(`</sheetData></worksheet>`)
+	return out.String()
+}
+
+// xlsxCell renders a typed cell and returns it with its display width.
+func xlsxCell(ref string, v interface{}, sst *sharedStrings) (string, int) {
+	switch t := v.(type) {
+	case nil:
+		return "", 0
+	case bool:
+		b := 0
+		if t {
+			b = 1
+		}
+		return fmt.Sprintf(`<c r="%s" t="b"><v>%d</v></c>`, ref, b), 5
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		s := fmt.Sprint(t)
+		return fmt.Sprintf(`<c r="%s"><v>%s</v></c>`, ref, s), len(s)
+	case float32:
+		return xlsxFloat(ref, float64(t), sst)
+	case float64:
+		return xlsxFloat(ref, t, sst)
+	case time.Time:
+		style, width := styleDateTime, 19
+		if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+			style, width = styleDate, 10
+		}
+		return fmt.Sprintf(`<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(excelSerial(t), 'f', -1, 64)), width
+	}
+	s := fmt.Sprint(v)
+	return fmt.Sprintf(`<c r="%s" t="s"><v>%d</v></c>`, ref, sst.add(s)), utf8.RuneCountInString(s)
+}
+
+func xlsxFloat(ref string, f float64, sst *sharedStrings) (string, int) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		s := strconv.FormatFloat(f, 'g', -1, 64)
+		return fmt.Sprintf(`<c r="%s" t="s"><v>%d</v></c>`, ref, sst.add(s)), len(s)
+	}
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	return fmt.Sprintf(`<c r="%s" s="%d"><v>%s</v></c>`, ref, styleFloat, s), len(strconv.FormatFloat(f, 'f', 2, 64))
+}
+
+// excelSerial converts t (wall clock, time zone ignored) to an Excel date
+// serial number in the 1900 date system.
+func excelSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	return wall.Sub(epoch).Hours() / 24
+}
+
+// cellRef returns the column letters for a zero-based index (0 -> A, 26 -> AA).
+func cellRef(i int) string {
+	var b []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		b = append([]byte{byte('A' + (i-1)%26)}, b...)
+	}
+	return string(b)
+}
+
+// sheetNames makes valid, unique sheet names: at most 31 characters and
+// none of []:*?/\.
+func sheetNames(sheets []xlsxSheet) []string {
+	used := map[string]bool{}
+	names := make([]string, len(sheets))
+	for i, s := range sheets {
+		name := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`[]:*?/\`, r) {
+				return '_'
+			}
+			return r
+		}, s.name)
+		if name == "" {
+			name = fmt.Sprintf("Sheet%d", i+1)
+		}
+		name = truncateRunes(name, 31)
+		base := name
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			suffix := fmt.Sprintf(" (%d)", n)
+			name = truncateRunes(base, 31-len(suffix)) + suffix
+		}
+		used[strings.ToLower(name)] = true
+		names[i] = name
+	}
+	return names
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// xmlEscape escapes text for XML, dropping characters XML cannot carry.
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func valueOr(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,225 @@
+package outputs
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+type xlsxCellXML struct {
+	Ref   string `xml:"r,attr"`
+	Type  string `xml:"t,attr"`
+	Style int    `xml:"s,attr"`
+	Value string `xml:"v"`
+}
+
+type xlsxSheetXML struct {
+	Dimension struct {
+		Ref string `xml:"ref,attr"`
+	} `xml:"dimension"`
+	Pane struct {
+		State string `xml:"state,attr"`
+	} `xml:"sheetViews>sheetView>pane"`
+	Rows []struct {
+		Num   int           `xml:"r,attr"`
+		Cells []xlsxCellXML `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type xlsxSSTXML struct {
+	Count       int      `xml:"count,attr"`
+	UniqueCount int      `xml:"uniqueCount,attr"`
+	Strings     []string `xml:"si>t"`
+}
+
+type xlsxWorkbookXML struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// unzipXLSX returns the parts of the workbook at path by name.
+func unzipXLSX(t *testing.T, path string) map[string][]byte {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("not a zip file: %v", err)
+	}
+	parts := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name], err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return parts
+}
+
+func decodePart(t *testing.T, parts map[string][]byte, name string, v interface{}) {
+	t.Helper()
+	b, ok := parts[name]
+	if !ok {
+		t.Fatalf("workbook has no %s", name)
+	}
+	if err := xml.Unmarshal(b, v); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+}
+
+func generateXLSX(t *testing.T, g XLSXOutputGenerator, data cronyx.DataPayload) map[string][]byte {
+	t.Helper()
+	g.OutDir = t.TempDir()
+	g.FileName = "report.{{.Format}}"
+	f, err := g.Generate(context.Background(), cronyx.RenderedDoc{Data: data}, "xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return unzipXLSX(t, f.Path)
+}
+
+func TestXLSXCells(t *testing.T) {
+	parts := generateXLSX(t, XLSXOutputGenerator{}, cronyx.DataPayload{
+		Columns: []string{"name", "count", "ratio", "ok", "day", "at", "note"},
+		Rows: []map[string]interface{}{
+			{"name": "north", "count": int64(42), "ratio": 0.5, "ok": true,
+				"day": time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), "at": time.Date(2025, 1, 1, 18, 0, 0, 0, time.UTC),
+				"note": "a < b & c"},
+			{"name": "south", "count": 7, "ratio": math.NaN(), "ok": false, "note": "north"},
+		},
+	})
+
+	for _, name := range []string{"[Content_Types].xml", "xl/_rels/workbook.xml.rels"} {
+		if !bytes.Contains(parts[name], []byte("sharedStrings")) {
+			t.Errorf("%s doesn't reference the shared strings part", name)
+		}
+	}
+	var sst xlsxSSTXML
+	decodePart(t, parts, "xl/sharedStrings.xml", &sst)
+	var sheet xlsxSheetXML
+	decodePart(t, parts, "xl/worksheets/sheet1.xml", &sheet)
+
+	if sheet.Dimension.Ref != "A1:G3" || sheet.Pane.State != "frozen" {
+		t.Errorf("dimension %q, pane %q; want A1:G3 and a frozen header", sheet.Dimension.Ref, sheet.Pane.State)
+	}
+	// text cells are resolved through the string table
+	text := func(c xlsxCellXML) string {
+		i, err := strconv.Atoi(c.Value)
+		if err != nil || i >= len(sst.Strings) {
+			t.Fatalf("cell %s: bad string index %q", c.Ref, c.Value)
+		}
+		return sst.Strings[i]
+	}
+	type cell struct {
+		ref, typ string
+		style    int
+		value    string // the resolved text for shared strings
+	}
+	want := [][]cell{
+		{{"A1", "s", styleHeader, "name"}, {"B1", "s", styleHeader, "count"}, {"C1", "s", styleHeader, "ratio"},
+			{"D1", "s", styleHeader, "ok"}, {"E1", "s", styleHeader, "day"}, {"F1", "s", styleHeader, "at"}, {"G1", "s", styleHeader, "note"}},
+		{{"A2", "s", 0, "north"}, {"B2", "", 0, "42"}, {"C2", "", styleFloat, "0.5"}, {"D2", "b", 0, "1"},
+			{"E2", "", styleDate, "45658"}, {"F2", "", styleDateTime, "45658.75"}, {"G2", "s", 0, "a < b & c"}},
+		// missing values leave no cell
+		{{"A3", "s", 0, "south"}, {"B3", "", 0, "7"}, {"C3", "s", 0, "NaN"}, {"D3", "b", 0, "0"}, {"G3", "s", 0, "north"}},
+	}
+	if len(sheet.Rows) != len(want) {
+		t.Fatalf("sheet has %d rows, want %d", len(sheet.Rows), len(want))
+	}
+	for r, row := range sheet.Rows {
+		var got []cell
+		for _, c := range row.Cells {
+			v := c.Value
+			if c.Type == "s" {
+				v = text(c)
+			}
+			got = append(got, cell{c.Ref, c.Type, c.Style, v})
+		}
+		if !reflect.DeepEqual(got, want[r]) {
+			t.Errorf("row %d:\n got %v\nwant %v", row.Num, got, want[r])
+		}
+	}
+
+	// "north" is stored once
+	if sst.Count != 12 || sst.UniqueCount != 11 || len(sst.Strings) != sst.UniqueCount {
+		t.Errorf("sst count %d, uniqueCount %d, %d strings; want 12, 11, 11", sst.Count, sst.UniqueCount, len(sst.Strings))
+	}
+}
+
+func TestXLSXSheets(t *testing.T) {
+	parts := generateXLSX(t, XLSXOutputGenerator{SheetName: "Sales"}, cronyx.DataPayload{
+		Rows: []map[string]interface{}{{"region": "north", "total": 10}},
+		Sets: []cronyx.DataSet{
+			{Name: "Q1/Q2", Columns: []string{"quarter"}, Rows: []map[string]interface{}{{"quarter": "Q1"}, {"quarter": "Q2"}}},
+			{Name: "sales", Rows: []map[string]interface{}{{"region": "south"}}},
+			{Name: strings.Repeat("x", 40)},
+		},
+	})
+
+	var wb xlsxWorkbookXML
+	decodePart(t, parts, "xl/workbook.xml", &wb)
+	var names []string
+	for _, s := range wb.Sheets {
+		names = append(names, s.Name)
+	}
+	wantNames := []string{"Sales", "Q1_Q2", "sales (2)", strings.Repeat("x", 31)}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("sheets = %q, want %q", names, wantNames)
+	}
+
+	var sst xlsxSSTXML
+	decodePart(t, parts, "xl/sharedStrings.xml", &sst)
+	firstCells := []string{}
+	for i := range wantNames {
+		var sheet xlsxSheetXML
+		decodePart(t, parts, fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), &sheet)
+		if len(sheet.Rows) == 0 || len(sheet.Rows[0].Cells) == 0 {
+			firstCells = append(firstCells, "")
+			continue
+		}
+		n, _ := strconv.Atoi(sheet.Rows[0].Cells[0].Value)
+		firstCells = append(firstCells, sst.Strings[n])
+	}
+	// columns without a loader order are sorted; the empty set keeps an empty sheet
+	if want := []string{"region", "quarter", "region", ""}; !reflect.DeepEqual(firstCells, want) {
+		t.Errorf("first header cells = %q, want %q", firstCells, want)
+	}
+}
+
+func TestXLSXFormats(t *testing.T) {
+	parts := generateXLSX(t, XLSXOutputGenerator{DateFormat: "dd/mm/yyyy", FloatFormat: "0.0%"}, cronyx.DataPayload{})
+	styles := string(parts["xl/styles.xml"])
+	for _, want := range []string{`formatCode="dd/mm/yyyy"`, `formatCode="yyyy-mm-dd hh:mm:ss"`, `formatCode="0.0%"`} {
+		if !strings.Contains(styles, want) {
+			t.Errorf("styles.xml lacks %s", want)
+		}
+	}
+}
+
+func TestCellRef(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := cellRef(i); got != want {
+			t.Errorf("cellRef(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
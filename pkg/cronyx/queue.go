@@ -0,0 +1,217 @@
+package cronyx
+
+import "context"
+
+// OverflowPolicy decides what happens to a trigger (a scheduled fire or
+// an Enqueue call) when the job queue is full. Runs held back by a
+// concurrency policy or label limit keep their queue slot until they
+// start, so they count towards Config.QueueSize.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for a free slot. Scheduled triggers wait in
+	// their own goroutine; Enqueue waits until the queue has room.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the new trigger; Enqueue returns ErrQueueFull.
+	OverflowDropNewest
+	// OverflowDropOldest evicts the oldest queued or held run to make room.
+	OverflowDropOldest
+	// OverflowCoalesce drops a trigger when a run of the same job ID is
+	// already queued or held, whether or not the queue is full. A trigger that
+	// doesn't match a queued run is dropped when the queue is full.
+	OverflowCoalesce
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropNewest:
+		return "drop-newest"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowCoalesce:
+		return "coalesce"
+	}
+	return "unknown"
+}
+
+// Enqueue queues a manual run of job and returns its run ID, handling a
+// full queue according to Config.Overflow. When OverflowCoalesce drops the
+// trigger, the ID of the run already queued is returned. Enqueue fails
+// with ErrEngineStopped once the engine is shut down.
+func (e *Engine) Enqueue(job ReportJob) (string, error) {
+	return e.EnqueueContext(context.Background(), job)
+}
+
+// EnqueueContext is like Enqueue, but gives up waiting for a free slot
+// when ctx is done and returns ctx.Err().
+func (e *Engine) EnqueueContext(ctx context.Context, job ReportJob) (string, error) {
+	return e.enqueue(ctx, queuedRun{runID: generateRunID(), job: job, trigger: TriggerManual}, e.overflow)
+}
+
+// TryEnqueue is like Enqueue but never waits: with OverflowBlock a full
+// queue returns ErrQueueFull.
+func (e *Engine) TryEnqueue(job ReportJob) (string, error) {
+	policy := e.overflow
+	if policy == OverflowBlock {
+		policy = OverflowDropNewest
+	}
+	return e.enqueue(context.Background(), queuedRun{runID: generateRunID(), job: job, trigger: TriggerManual}, policy)
+}
+
+func (e *Engine) enqueue(ctx context.Context, qr queuedRun, policy OverflowPolicy) (string, error) {
+	select {
+	case <-e.stopCh:
+		return "", ErrEngineStopped
+	default:
+	}
+
+	e.queueMu.Lock()
+	if ids := e.pending[qr.job.ID]; policy == OverflowCoalesce && len(ids) > 0 {
+		e.queueMu.Unlock()
+		e.dropTrigger(qr, "a run of the job is already queued")
+		return ids[0], nil
+	}
+	if e.trySendLocked(qr) {
+		e.queueMu.Unlock()
+		e.replaceActive(qr)
+		return qr.runID, nil
+	}
+
+	switch policy {
+	case OverflowBlock:
+		// count the run as queued while waiting, so that it can be found
+		// by CancelRun and is accounted for as soon as it lands
+		e.queued[qr.job.ID] = append(e.queued[qr.job.ID], qr.runID)
+		e.queueMu.Unlock()
+		select {
+		case e.slots <- struct{}{}:
+			qr.queued = true
+			e.queueMu.Lock()
+			e.pending[qr.job.ID] = append(e.pending[qr.job.ID], qr.runID)
+			e.jobQueue <- qr
+			e.queueMu.Unlock()
+			e.replaceActive(qr)
+			return qr.runID, nil
+		case <-ctx.Done():
+			e.dequeued(qr)
+			return "", ctx.Err()
+		case <-e.stopCh:
+			e.dequeued(qr)
+			return "", ErrEngineStopped
+		}
+
+	case OverflowDropOldest:
+		e.queueMu.Unlock()
+		for {
+			select {
+			case <-e.stopCh:
+				return "", ErrEngineStopped
+			default:
+			}
+			if old, ok := e.evictOldest(); ok {
+				e.dropTrigger(old, "evicted by a newer trigger")
+			}
+			// otherwise a worker is between taking a run off the queue
+			// and holding it, or freed a slot in the meantime
+			e.queueMu.Lock()
+			if e.trySendLocked(qr) {
+				e.queueMu.Unlock()
+				e.replaceActive(qr)
+				return qr.runID, nil
+			}
+			e.queueMu.Unlock()
+		}
+	}
+
+	e.queueMu.Unlock()
+	e.dropTrigger(qr, "queue full")
+	return "", ErrQueueFull
+}
+
+// trySendLocked queues qr if it can take a queue slot. queueMu must be
+// held.
+func (e *Engine) trySendLocked(qr queuedRun) bool {
+	select {
+	case e.slots <- struct{}{}:
+	default:
+		return false
+	}
+	qr.queued = true
+	// never blocks: jobQueue has room for every slot
+	e.jobQueue <- qr
+	e.queued[qr.job.ID] = append(e.queued[qr.job.ID], qr.runID)
+	e.pending[qr.job.ID] = append(e.pending[qr.job.ID], qr.runID)
+	return true
+}
+
+// evictOldest removes the oldest run holding a queue slot, which is the
+// oldest held run or else the run at the head of the queue.
+func (e *Engine) evictOldest() (queuedRun, bool) {
+	e.runsMu.Lock()
+	defer e.runsMu.Unlock()
+	for i, qr := range e.held {
+		if qr.queued {
+			e.held = append(e.held[:i], e.held[i+1:]...)
+			return qr, true
+		}
+	}
+	select {
+	case qr := <-e.jobQueue:
+		e.dequeued(qr)
+		return qr, true
+	default:
+		return queuedRun{}, false
+	}
+}
+
+// releaseSlot frees the queue slot of a queued run once it starts or ends
+// without starting.
+func (e *Engine) releaseSlot(qr queuedRun) {
+	if !qr.queued {
+		return
+	}
+	e.queueMu.Lock()
+	e.pending[qr.job.ID] = removeID(e.pending[qr.job.ID], qr.runID)
+	if len(e.pending[qr.job.ID]) == 0 {
+		delete(e.pending, qr.job.ID)
+	}
+	e.queueMu.Unlock()
+	<-e.slots
+}
+
+func removeID(ids []string, id string) []string {
+	for i, x := range ids {
+		if x == id {
+			return append(ids[:i], ids[i+1:]...)
+		}
+	}
+	return ids
+}
+
+// dequeued updates the queue accounting for a run taken off the queue and
+// reports whether it was cancelled while queued.
+func (e *Engine) dequeued(qr queuedRun) bool {
+	e.queueMu.Lock()
+	defer e.queueMu.Unlock()
+	return e.dequeuedLocked(qr)
+}
+
+func (e *Engine) dequeuedLocked(qr queuedRun) bool {
+	ids := removeID(e.queued[qr.job.ID], qr.runID)
+	if len(ids) == 0 {
+		delete(e.queued, qr.job.ID)
+	} else {
+		e.queued[qr.job.ID] = ids
+	}
+	cancelled := e.cancel[qr.runID]
+	delete(e.cancel, qr.runID)
+	return cancelled
+}
+
+func (e *Engine) dropTrigger(qr queuedRun, reason string) {
+	e.logger.Printf("cronyx: job %s: dropped %s trigger (run %s): %s", qr.job.ID, qr.trigger, qr.runID, reason)
+	e.metrics.addDropped(qr.job.ID)
+	e.recordUnstarted(qr, RunDropped, reason)
+}
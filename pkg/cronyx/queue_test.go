@@ -0,0 +1,108 @@
+package cronyx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestOverflowCountsHeldRuns fills a label-limited job past QueueSize: the
+// runs held by the limit keep their queue slots, so the overflow policy
+// applies although the queue channel itself is empty.
+func TestOverflowCountsHeldRuns(t *testing.T) {
+	tests := []struct {
+		policy OverflowPolicy
+		check  func(t *testing.T, e *Engine, job ReportJob, held []string)
+	}{
+		{
+			policy: OverflowBlock,
+			check: func(t *testing.T, e *Engine, job ReportJob, held []string) {
+				if _, err := e.TryEnqueue(job); !errors.Is(err, ErrQueueFull) {
+					t.Errorf("TryEnqueue: err = %v, want ErrQueueFull", err)
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+				defer cancel()
+				if _, err := e.EnqueueContext(ctx, job); !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("EnqueueContext: err = %v, want DeadlineExceeded", err)
+				}
+			},
+		},
+		{
+			policy: OverflowDropNewest,
+			check: func(t *testing.T, e *Engine, job ReportJob, held []string) {
+				if _, err := e.Enqueue(job); !errors.Is(err, ErrQueueFull) {
+					t.Errorf("Enqueue: err = %v, want ErrQueueFull", err)
+				}
+				waitDropped(t, e, 1)
+			},
+		},
+		{
+			policy: OverflowDropOldest,
+			check: func(t *testing.T, e *Engine, job ReportJob, held []string) {
+				if _, err := e.Enqueue(job); err != nil {
+					t.Fatalf("Enqueue: %v", err)
+				}
+				waitRun(t, e, held[0], RunDropped)
+				waitFor(t, "the new run to be held", func() bool { return heldCount(e) == 2 })
+			},
+		},
+		{
+			policy: OverflowCoalesce,
+			check: func(t *testing.T, e *Engine, job ReportJob, held []string) {
+				id, err := e.Enqueue(job)
+				if err != nil || id != held[0] {
+					t.Errorf("Enqueue = %s, %v; want the held run %s", id, err, held[0])
+				}
+				other := job
+				other.ID = "third"
+				if _, err := e.Enqueue(other); !errors.Is(err, ErrQueueFull) {
+					t.Errorf("Enqueue(other job): err = %v, want ErrQueueFull", err)
+				}
+				waitDropped(t, e, 2)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			e, l := newTestEngine(t, Config{
+				Workers:     2,
+				QueueSize:   2,
+				Overflow:    tt.policy,
+				LabelLimits: map[string]int{"db=main": 1},
+			})
+			// distinct job IDs, so that coalescing doesn't merge them
+			labelled := func(id string) ReportJob {
+				job := testJob(id, ConcurrencyAllow)
+				job.Labels = map[string]string{"db": "main"}
+				return job
+			}
+			running := mustEnqueue(t, e, labelled("running"))
+			l.waitStarted(t)
+			held := []string{mustEnqueue(t, e, labelled("first")), mustEnqueue(t, e, labelled("second"))}
+			job := labelled("first")
+			waitFor(t, "held runs", func() bool { return heldCount(e) == 2 })
+			if m := e.GetMetrics(); m.QueueDepth != 2 || m.QueueCapacity != 2 {
+				t.Errorf("QueueDepth = %d/%d, want 2/2", m.QueueDepth, m.QueueCapacity)
+			}
+
+			tt.check(t, e, job, held)
+
+			// held runs free their slots as they start
+			l.releaseAll()
+			waitRun(t, e, running, RunSucceeded)
+			waitFor(t, "the queue to empty", func() bool { return e.GetMetrics().QueueDepth == 0 })
+			if _, err := e.TryEnqueue(job); err != nil {
+				t.Errorf("TryEnqueue after draining: %v", err)
+			}
+		})
+	}
+}
+
+func waitDropped(t *testing.T, e *Engine, n int) {
+	t.Helper()
+	waitFor(t, "dropped runs", func() bool {
+		runs, _ := e.ListRuns(context.Background(), RunQuery{Status: RunDropped})
+		return len(runs) == n
+	})
+}
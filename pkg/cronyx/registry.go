@@ -0,0 +1,206 @@
+package cronyx
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ScheduledJob is a snapshot of a job in the engine's cron registry.
+type ScheduledJob struct {
+	Job    ReportJob
+	Paused bool
+	// Next is the next fire time; zero while paused.
+	Next time.Time
+	// Prev is the last time the job fired; zero if it hasn't yet.
+	Prev time.Time
+}
+
+// scheduledJob is a registry entry. Fields are guarded by Engine.jobsMu.
+type scheduledJob struct {
+	job      ReportJob
+	schedule cron.Schedule
+	entryID  cron.EntryID // zero while paused
+	paused   bool
+	prev     time.Time
+}
+
+// AddCronJob validates job and schedules it under its ID. IDs must be
+// unique; use UpdateJob to change a scheduled job. The components the job
+// uses must be registered before it is added (see ValidateJob) unless
+// Config.DeferValidation is set.
+func (e *Engine) AddCronJob(job ReportJob) error {
+	sched, err := e.prepareJob(job)
+	if err != nil {
+		return err
+	}
+
+	e.jobsMu.Lock()
+	defer e.jobsMu.Unlock()
+	if _, ok := e.jobs[job.ID]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateJob, job.ID)
+	}
+	sj := &scheduledJob{job: job, schedule: sched}
+	e.scheduleLocked(sj)
+	e.jobs[job.ID] = sj
+	return nil
+}
+
+// UpdateJob replaces the scheduled job with the same ID, rescheduling it
+// with the new schedule. The job is only swapped once the new definition
+// has been validated, and a paused job stays paused.
+func (e *Engine) UpdateJob(job ReportJob) error {
+	sched, err := e.prepareJob(job)
+	if err != nil {
+		return err
+	}
+
+	e.jobsMu.Lock()
+	defer e.jobsMu.Unlock()
+	sj, ok := e.jobs[job.ID]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrJobNotFound, job.ID)
+	}
+	e.unscheduleLocked(sj)
+	sj.job, sj.schedule = job, sched
+	if !sj.paused {
+		e.scheduleLocked(sj)
+	}
+	return nil
+}
+
+// RemoveJob unschedules a job. Runs already queued or running aren't
+// affected.
+func (e *Engine) RemoveJob(id string) error {
+	e.jobsMu.Lock()
+	defer e.jobsMu.Unlock()
+	sj, ok := e.jobs[id]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrJobNotFound, id)
+	}
+	e.unscheduleLocked(sj)
+	delete(e.jobs, id)
+	return nil
+}
+
+// PauseJob stops a job from firing until ResumeJob. Pausing a paused job
+// is a no-op.
+func (e *Engine) PauseJob(id string) error {
+	e.jobsMu.Lock()
+	defer e.jobsMu.Unlock()
+	sj, ok := e.jobs[id]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrJobNotFound, id)
+	}
+	e.unscheduleLocked(sj)
+	sj.paused = true
+	return nil
+}
+
+// ResumeJob reschedules a paused job. Fire times missed while paused are
+// skipped.
+func (e *Engine) ResumeJob(id string) error {
+	e.jobsMu.Lock()
+	defer e.jobsMu.Unlock()
+	sj, ok := e.jobs[id]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrJobNotFound, id)
+	}
+	if sj.paused {
+		sj.paused = false
+		e.scheduleLocked(sj)
+	}
+	return nil
+}
+
+// GetJob returns the scheduled job with the given ID.
+func (e *Engine) GetJob(id string) (ScheduledJob, error) {
+	e.jobsMu.Lock()
+	defer e.jobsMu.Unlock()
+	sj, ok := e.jobs[id]
+	if !ok {
+		return ScheduledJob{}, fmt.Errorf("%w: %q", ErrJobNotFound, id)
+	}
+	return e.snapshotLocked(sj), nil
+}
+
+// ListJobs returns all scheduled jobs ordered by ID.
+func (e *Engine) ListJobs() []ScheduledJob {
+	e.jobsMu.Lock()
+	defer e.jobsMu.Unlock()
+	jobs := make([]ScheduledJob, 0, len(e.jobs))
+	for _, sj := range e.jobs {
+		jobs = append(jobs, e.snapshotLocked(sj))
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Job.ID < jobs[j].Job.ID })
+	return jobs
+}
+
+// prepareJob validates a job for the registry and parses its schedule.
+func (e *Engine) prepareJob(job ReportJob) (cron.Schedule, error) {
+	select {
+	case <-e.stopCh:
+		return nil, ErrEngineStopped
+	default:
+	}
+	if job.ID == "" {
+		return nil, fmt.Errorf("cronyx: scheduled jobs need an ID")
+	}
+	if job.Schedule == "" {
+		return nil, fmt.Errorf("%w: empty schedule", ErrInvalidSchedule)
+	}
+	if !e.deferCheck {
+		if err := e.ValidateJob(job); err != nil {
+			return nil, err
+		}
+	}
+	if !job.Concurrency.valid() {
+		return nil, fmt.Errorf("cronyx: unknown concurrency policy %q", job.Concurrency)
+	}
+	if err := job.Retry.validate(); err != nil {
+		return nil, fmt.Errorf("cronyx: job %s: %w", job.ID, err)
+	}
+	for _, dCfg := range job.Delivery {
+		if _, err := ParseRetryPolicy(dCfg); err != nil {
+			return nil, fmt.Errorf("cronyx: job %s: delivery %s: %w", job.ID, dCfg["type"], err)
+		}
+	}
+	sched, err := e.cronParser.Parse(job.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	return sched, nil
+}
+
+func (e *Engine) scheduleLocked(sj *scheduledJob) {
+	job := sj.job
+	sj.entryID = e.cronSched.Schedule(sj.schedule, cron.FuncJob(func() {
+		e.jobsMu.Lock()
+		sj.prev = time.Now()
+		e.jobsMu.Unlock()
+		// enqueue on schedule; drops are logged and counted by enqueue
+		_, _ = e.enqueue(context.Background(), queuedRun{runID: generateRunID(), job: job, trigger: TriggerSchedule}, e.overflow)
+	}))
+}
+
+func (e *Engine) unscheduleLocked(sj *scheduledJob) {
+	if sj.entryID != 0 {
+		e.cronSched.Remove(sj.entryID)
+		sj.entryID = 0
+	}
+}
+
+func (e *Engine) snapshotLocked(sj *scheduledJob) ScheduledJob {
+	s := ScheduledJob{Job: sj.job, Paused: sj.paused, Prev: sj.prev}
+	if !sj.paused {
+		// the scheduler only fills in Next once it is running
+		s.Next = e.cronSched.Entry(sj.entryID).Next
+		if s.Next.IsZero() {
+			s.Next = sj.schedule.Next(time.Now().In(e.location))
+		}
+	}
+	return s
+}
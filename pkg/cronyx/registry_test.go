@@ -0,0 +1,181 @@
+package cronyx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+type nopDelivery struct{}
+
+func (nopDelivery) Deliver(ctx context.Context, target DeliveryConfig, files []OutputFile) error {
+	return nil
+}
+
+func cronJob(id, schedule string) ReportJob {
+	job := testJob(id, ConcurrencyAllow)
+	job.Schedule = schedule
+	return job
+}
+
+func TestRegistry(t *testing.T) {
+	e, _ := newTestEngine(t, Config{})
+	if err := e.AddCronJob(cronJob("weekly", "0 0 9 * * 1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddCronJob(cronJob("daily", "0 0 9 * * *")); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddCronJob(cronJob("daily", "0 0 10 * * *")); !errors.Is(err, ErrDuplicateJob) {
+		t.Errorf("adding a taken ID: err = %v, want ErrDuplicateJob", err)
+	}
+
+	var ids []string
+	for _, j := range e.ListJobs() {
+		ids = append(ids, j.Job.ID)
+		if j.Next.IsZero() || j.Paused {
+			t.Errorf("%s: Next %v, Paused %v", j.Job.ID, j.Next, j.Paused)
+		}
+	}
+	if !equalStrings(ids, []string{"daily", "weekly"}) {
+		t.Errorf("ListJobs = %v, want daily, weekly", ids)
+	}
+
+	if err := e.PauseJob("daily"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.UpdateJob(cronJob("daily", "0 30 18 * * *")); err != nil {
+		t.Fatal(err)
+	}
+	j, _ := e.GetJob("daily")
+	if !j.Paused || !j.Next.IsZero() || j.Job.Schedule != "0 30 18 * * *" {
+		t.Errorf("updated paused job = %+v, want it paused with the new schedule", j)
+	}
+	if err := e.UpdateJob(cronJob("daily", "not a schedule")); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("invalid update: err = %v, want ErrInvalidSchedule", err)
+	}
+	if j, _ := e.GetJob("daily"); j.Job.Schedule != "0 30 18 * * *" {
+		t.Errorf("invalid update replaced the schedule with %q", j.Job.Schedule)
+	}
+
+	if err := e.ResumeJob("daily"); err != nil {
+		t.Fatal(err)
+	}
+	j, _ = e.GetJob("daily")
+	if next := j.Next.In(time.Local); j.Paused || next.Hour() != 18 || next.Minute() != 30 {
+		t.Errorf("resumed job = %+v, want the next 18:30", j)
+	}
+
+	if err := e.RemoveJob("daily"); err != nil {
+		t.Fatal(err)
+	}
+	for name, err := range map[string]error{
+		"GetJob":    func() error { _, err := e.GetJob("daily"); return err }(),
+		"RemoveJob": e.RemoveJob("daily"),
+		"PauseJob":  e.PauseJob("daily"),
+		"ResumeJob": e.ResumeJob("daily"),
+		"UpdateJob": e.UpdateJob(cronJob("daily", "0 0 9 * * *")),
+	} {
+		if !errors.Is(err, ErrJobNotFound) {
+			t.Errorf("%s of a removed job: err = %v, want ErrJobNotFound", name, err)
+		}
+	}
+}
+
+func TestAddCronJobValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*ReportJob)
+		wantErr error
+		stage   Stage
+	}{
+		{"no ID", func(j *ReportJob) { j.ID = "" }, nil, ""},
+		{"empty schedule", func(j *ReportJob) { j.Schedule = "" }, ErrInvalidSchedule, ""},
+		{"bad schedule", func(j *ReportJob) { j.Schedule = "0 9 * * *" }, ErrInvalidSchedule, ""},
+		{"concurrency", func(j *ReportJob) { j.Concurrency = "sometimes" }, nil, ""},
+		{"loader", func(j *ReportJob) { j.DataSource["type"] = "ftp" }, ErrNoLoader, StageLoad},
+		{"renderer", func(j *ReportJob) { j.Renderer = "latex" }, ErrNoRenderer, StageRender},
+		{"output", func(j *ReportJob) { j.Outputs = []string{"pptx"} }, ErrNoOutput, StageOutput},
+		{"delivery", func(j *ReportJob) { j.Delivery = []DeliveryConfig{{"type": "fax"}} }, ErrNoDelivery, StageDeliver},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, _ := newTestEngine(t, Config{})
+			job := cronJob("daily", "0 0 9 * * *")
+			tt.modify(&job)
+			err := e.AddCronJob(job)
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			var jobErr *JobError
+			if tt.stage != "" && (!errors.As(err, &jobErr) || jobErr.Stage != tt.stage) {
+				t.Errorf("err = %v, want a JobError for stage %s", err, tt.stage)
+			}
+			if n := len(e.ListJobs()); n != 0 {
+				t.Errorf("%d jobs scheduled after a failed add", n)
+			}
+		})
+	}
+}
+
+func TestDeferValidation(t *testing.T) {
+	e := NewEngineWithConfig(&Config{DeferValidation: true, Logger: log.New(io.Discard, "", 0)})
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		e.Shutdown(ctx)
+	})
+
+	// jobs go in before any component is registered
+	ready := cronJob("ready", "@every 1s")
+	ready.DataSource["type"] = "json"
+	ready.Delivery = []DeliveryConfig{{"type": "archive"}}
+	missing := ready
+	missing.ID = "missing"
+	missing.Delivery = []DeliveryConfig{{"type": "fax"}}
+	for _, job := range []ReportJob{ready, missing} {
+		if err := e.AddCronJob(job); err != nil {
+			t.Fatalf("AddCronJob(%s): %v", job.ID, err)
+		}
+	}
+	// schedules are still checked up front
+	if err := e.UpdateJob(cronJob("ready", "soon")); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("UpdateJob: err = %v, want ErrInvalidSchedule", err)
+	}
+
+	e.RegisterLoader("json", nopLoader{})
+	e.RegisterRenderer("text", nopRenderer{})
+	e.RegisterDelivery("archive", nopDelivery{})
+	e.Start()
+
+	lastRun := func(jobID string) JobRun {
+		var run JobRun
+		waitFor(t, "a run of "+jobID, func() bool {
+			runs, _ := e.ListRuns(context.Background(), RunQuery{JobID: jobID})
+			for _, r := range runs {
+				if r.Status == RunSucceeded || r.Status == RunFailed {
+					run = r
+					return true
+				}
+			}
+			return false
+		})
+		return run
+	}
+	if run := lastRun("ready"); run.Status != RunSucceeded {
+		t.Errorf("ready run = %+v, want it to succeed", run)
+	}
+	if run := lastRun("missing"); run.Status != RunFailed || !strings.Contains(run.Error, ErrNoDelivery.Error()) {
+		t.Errorf("missing run = %+v, want it failed with %v", run, ErrNoDelivery)
+	}
+}
+
+type nopLoader struct{}
+
+func (nopLoader) Load(ctx context.Context, cfg DataSourceConfig) (DataPayload, error) {
+	return DataPayload{}, nil
+}
@@ -0,0 +1,41 @@
+package renderers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+// HTMLRenderer renders HTML templates with html/template, so row values
+// are escaped automatically.
+type HTMLRenderer struct{}
+
+func (r HTMLRenderer) Render(ctx context.Context, tplPath string, data cronyx.DataPayload) (cronyx.RenderedDoc, error) {
+	return r.RenderWithOptions(ctx, tplPath, data, nil)
+}
+
+func (HTMLRenderer) RenderWithOptions(ctx context.Context, tplPath string, data cronyx.DataPayload, opts cronyx.RendererOptions) (cronyx.RenderedDoc, error) {
+	src, err := readTemplate(tplPath)
+	if err != nil {
+		return cronyx.RenderedDoc{}, err
+	}
+
+	tmpl, err := template.New("report").Delims(delims(opts)).Funcs(template.FuncMap(funcMap())).Parse(src)
+	if err != nil {
+		return cronyx.RenderedDoc{}, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, templateData(tplPath, data)); err != nil {
+		return cronyx.RenderedDoc{}, fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	return cronyx.RenderedDoc{
+		HTML:    buf.String(),
+		Content: buf.String(),
+		Meta:    docMeta(tplPath, "html", data),
+	}, nil
+}
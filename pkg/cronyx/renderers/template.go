@@ -0,0 +1,165 @@
+package renderers
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+// funcMap holds the template functions shared by all renderers.
+func funcMap() template.FuncMap {
+	return template.FuncMap{
+		"len": func(slice interface{}) int {
+			switch v := slice.(type) {
+			case []map[string]interface{}:
+				return len(v)
+			default:
+				return 0
+			}
+		},
+		"add": arith(func(a, b int64) int64 { return a + b }, func(a, b float64) float64 { return a + b }),
+		"sub": arith(func(a, b int64) int64 { return a - b }, func(a, b float64) float64 { return a - b }),
+		"mul": arith(func(a, b int64) int64 { return a * b }, func(a, b float64) float64 { return a * b }),
+		"div": func(a, b interface{}) (interface{}, error) {
+			x, err := toFloat(a)
+			if err != nil {
+				return nil, err
+			}
+			y, err := toFloat(b)
+			if err != nil {
+				return nil, err
+			}
+			if y == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return x / y, nil
+		},
+	}
+}
+
+// arith builds a template function that applies intOp when both operands
+// are integers and floatOp otherwise. Numeric strings are accepted so that
+// untyped CSV columns still work.
+func arith(intOp func(a, b int64) int64, floatOp func(a, b float64) float64) func(a, b interface{}) (interface{}, error) {
+	return func(a, b interface{}) (interface{}, error) {
+		xi, xf, xInt, err := toNumber(a)
+		if err != nil {
+			return nil, err
+		}
+		yi, yf, yInt, err := toNumber(b)
+		if err != nil {
+			return nil, err
+		}
+		if xInt && yInt {
+			return intOp(xi, yi), nil
+		}
+		return floatOp(xf, yf), nil
+	}
+}
+
+// toNumber converts a template value to a number. isInt reports whether it
+// was an integer, in which case i holds the exact value.
+func toNumber(v interface{}) (i int64, f float64, isInt bool, err error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), float64(n), true, nil
+	case int32:
+		return int64(n), float64(n), true, nil
+	case int64:
+		return n, float64(n), true, nil
+	case uint:
+		return int64(n), float64(n), true, nil
+	case uint64:
+		return int64(n), float64(n), true, nil
+	case float32:
+		return 0, float64(n), false, nil
+	case float64:
+		return 0, n, false, nil
+	case nil:
+		return 0, 0, true, nil
+	case string:
+		s := strings.TrimSpace(n)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, float64(i), true, nil
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return 0, f, false, nil
+		}
+	}
+	return 0, 0, false, fmt.Errorf("not a number: %v", v)
+}
+
+func toFloat(v interface{}) (float64, error) {
+	_, f, _, err := toNumber(v)
+	return f, err
+}
+
+// readTemplate loads the template source from disk.
+func readTemplate(tplPath string) (string, error) {
+	b, err := ioutil.ReadFile(tplPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template: %w", err)
+	}
+	return string(b), nil
+}
+
+// delims returns the template delimiters from the renderer options,
+// defaulting to "{{" and "}}".
+func delims(opts cronyx.RendererOptions) (string, string) {
+	left, right := opts["left_delim"], opts["right_delim"]
+	if left == "" {
+		left = "{{"
+	}
+	if right == "" {
+		right = "}}"
+	}
+	return left, right
+}
+
+// templateData prepares the data passed to the template, with metadata
+func templateData(tplPath string, data cronyx.DataPayload) map[string]interface{} {
+	return map[string]interface{}{
+		"Rows": data.Rows,
+		"Data": data.Rows, // alias for convenience
+		"Meta": map[string]interface{}{
+			"timestamp":  time.Now().Format("2006-01-02 15:04:05"),
+			"rows_count": len(data.Rows),
+			"source":     tplPath,
+		},
+	}
+}
+
+// docMeta is the metadata attached to every rendered document.
+func docMeta(tplPath, format string, data cronyx.DataPayload) map[string]interface{} {
+	return map[string]interface{}{
+		"source":     tplPath,
+		"format":     format,
+		"rows_count": len(data.Rows),
+		"timestamp":  time.Now().Format("2006-01-02 15:04:05"),
+	}
+}
+
+// renderText executes a text/template against the payload.
+func renderText(tplPath string, data cronyx.DataPayload, opts cronyx.RendererOptions) (string, error) {
+	src, err := readTemplate(tplPath)
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := template.New("report").Delims(delims(opts)).Funcs(funcMap()).Parse(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, templateData(tplPath, data)); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
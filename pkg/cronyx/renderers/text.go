@@ -0,0 +1,29 @@
+package renderers
+
+import (
+	"context"
+	"html"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+// TextRenderer renders plain-text templates. The HTML form wraps the text
+// in a <pre> block.
+type TextRenderer struct{}
+
+func (r TextRenderer) Render(ctx context.Context, tplPath string, data cronyx.DataPayload) (cronyx.RenderedDoc, error) {
+	return r.RenderWithOptions(ctx, tplPath, data, nil)
+}
+
+func (TextRenderer) RenderWithOptions(ctx context.Context, tplPath string, data cronyx.DataPayload, opts cronyx.RendererOptions) (cronyx.RenderedDoc, error) {
+	text, err := renderText(tplPath, data, opts)
+	if err != nil {
+		return cronyx.RenderedDoc{}, err
+	}
+
+	return cronyx.RenderedDoc{
+		HTML:    "<pre>" + html.EscapeString(text) + "</pre>",
+		Content: text,
+		Meta:    docMeta(tplPath, "text", data),
+	}, nil
+}
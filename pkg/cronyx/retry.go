@@ -0,0 +1,177 @@
+package cronyx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = time.Minute
+	defaultMultiplier     = 2.0
+)
+
+// RetryPolicy controls how failed attempts are retried. On a ReportJob it
+// applies to the load/render/output pipeline and to deliveries without a
+// policy of their own; a DeliveryConfig can override it with the retry_*
+// keys understood by ParseRetryPolicy.
+type RetryPolicy struct {
+	MaxAttempts    int           // total attempts including the first; <= 1 disables retries
+	InitialBackoff time.Duration // wait before the first retry (default 1s)
+	MaxBackoff     time.Duration // upper bound for the wait (default 1m)
+	Multiplier     float64       // backoff growth per attempt, at least 1 (default 2)
+	Jitter         float64       // randomizes each wait by +/- this fraction (0..1)
+	// Retryable reports whether an error is worth retrying. Defaults to
+	// DefaultRetryable.
+	Retryable func(error) bool
+}
+
+// Backoff returns the wait before retry number n (1 for the first retry).
+func (p *RetryPolicy) Backoff(n int) time.Duration {
+	initial, max, mult := p.InitialBackoff, p.MaxBackoff, p.Multiplier
+	if initial <= 0 {
+		initial = defaultInitialBackoff
+	}
+	if max <= 0 {
+		max = defaultMaxBackoff
+	}
+	if mult == 0 {
+		mult = defaultMultiplier
+	} else if mult < 1 {
+		// rejected by validate; never let the wait shrink
+		mult = 1
+	}
+
+	d := float64(initial) * math.Pow(mult, float64(n-1))
+	if d > float64(max) {
+		d = float64(max)
+	}
+	if p.Jitter > 0 {
+		j := math.Min(p.Jitter, 1)
+		d += d * j * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// validate rejects settings Backoff can't honour. A nil policy is valid.
+func (p *RetryPolicy) validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.Multiplier != 0 && p.Multiplier < 1 {
+		return fmt.Errorf("retry multiplier %v is below 1", p.Multiplier)
+	}
+	return nil
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return DefaultRetryable(err)
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying under DefaultRetryable.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// DefaultRetryable retries everything except cancellation, missing
+// components and errors wrapped with Permanent.
+func DefaultRetryable(err error) bool {
+	var perm *permanentError
+	switch {
+	case errors.As(err, &perm),
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, ErrNoLoader),
+		errors.Is(err, ErrNoRenderer),
+		errors.Is(err, ErrNoOutput),
+		errors.Is(err, ErrNoDelivery):
+		return false
+	}
+	return true
+}
+
+// ParseRetryPolicy reads a policy from string config keys:
+//
+//	retry_attempts     total attempts (required to enable retries)
+//	retry_backoff      initial backoff, e.g. "2s"
+//	retry_max_backoff  maximum backoff, e.g. "1m"
+//	retry_multiplier   backoff multiplier of at least 1, e.g. "1.5"
+//	retry_jitter       jitter fraction, e.g. "0.2"
+//
+// It returns nil when retry_attempts is absent.
+func ParseRetryPolicy(cfg map[string]string) (*RetryPolicy, error) {
+	attempts, ok := cfg["retry_attempts"]
+	if !ok || attempts == "" {
+		return nil, nil
+	}
+
+	p := &RetryPolicy{}
+	var err error
+	if p.MaxAttempts, err = strconv.Atoi(attempts); err != nil {
+		return nil, fmt.Errorf("invalid retry_attempts %q: %w", attempts, err)
+	}
+	if v := cfg["retry_backoff"]; v != "" {
+		if p.InitialBackoff, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("invalid retry_backoff %q: %w", v, err)
+		}
+	}
+	if v := cfg["retry_max_backoff"]; v != "" {
+		if p.MaxBackoff, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("invalid retry_max_backoff %q: %w", v, err)
+		}
+	}
+	if v := cfg["retry_multiplier"]; v != "" {
+		if p.Multiplier, err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("invalid retry_multiplier %q: %w", v, err)
+		}
+	}
+	if v := cfg["retry_jitter"]; v != "" {
+		if p.Jitter, err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("invalid retry_jitter %q: %w", v, err)
+		}
+	}
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("invalid retry_multiplier %q: %w", cfg["retry_multiplier"], err)
+	}
+	return p, nil
+}
+
+// retry calls fn until it succeeds, the policy is exhausted, the error is
+// not retryable or ctx is done. onRetry is called before each wait. A nil
+// policy makes a single attempt.
+func retry(ctx context.Context, p *RetryPolicy, fn func(attempt int) error, onRetry func(attempt int, wait time.Duration, err error)) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(attempt)
+		if err == nil || p == nil || attempt >= p.MaxAttempts || !p.retryable(err) {
+			return err
+		}
+
+		wait := p.Backoff(attempt)
+		if onRetry != nil {
+			onRetry(attempt, wait, err)
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package cronyx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		want   []time.Duration
+	}{
+		{"defaults", RetryPolicy{}, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}},
+		{
+			"capped",
+			RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond, Multiplier: 1.5},
+			[]time.Duration{100 * time.Millisecond, 150 * time.Millisecond, 225 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond},
+		},
+		{"constant", RetryPolicy{InitialBackoff: time.Second, Multiplier: 1}, []time.Duration{time.Second, time.Second, time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, want := range tt.want {
+				if got := tt.policy.Backoff(i + 1); got != want {
+					t.Errorf("Backoff(%d) = %v, want %v", i+1, got, want)
+				}
+			}
+		})
+	}
+
+	p := RetryPolicy{InitialBackoff: time.Second, Jitter: 0.25}
+	for i := 0; i < 100; i++ {
+		if d := p.Backoff(1); d < 750*time.Millisecond || d > 1250*time.Millisecond {
+			t.Fatalf("jittered Backoff(1) = %v, want 1s +/- 25%%", d)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	fail := errors.New("boom")
+	p := &RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Millisecond}
+	tests := []struct {
+		name     string
+		policy   *RetryPolicy
+		errs     []error // returned by successive attempts; nil afterwards
+		wantErr  error
+		attempts int
+	}{
+		{"succeeds after failures", p, []error{fail, fail}, nil, 3},
+		{"exhausted", p, []error{fail, fail, fail, fail, fail}, fail, 4},
+		{"nil policy", nil, []error{fail}, fail, 1},
+		{"permanent", p, []error{fail, Permanent(fail)}, fail, 2},
+		{"wrapped permanent", p, []error{fmt.Errorf("load: %w", Permanent(fail))}, fail, 1},
+		{"missing component", p, []error{&JobError{Stage: StageOutput, Err: ErrNoOutput}}, ErrNoOutput, 1},
+		{"custom retryable", &RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Millisecond, Retryable: func(err error) bool { return false }},
+			[]error{fail}, fail, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts, retries int
+			err := retry(context.Background(), tt.policy, func(attempt int) error {
+				attempts++
+				if attempt != attempts {
+					t.Errorf("attempt %d reported as %d", attempts, attempt)
+				}
+				if attempt <= len(tt.errs) {
+					return tt.errs[attempt-1]
+				}
+				return nil
+			}, func(int, time.Duration, error) { retries++ })
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if attempts != tt.attempts || retries != attempts-1 {
+				t.Errorf("%d attempts, %d retries; want %d attempts", attempts, retries, tt.attempts)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	err := retry(ctx, &RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour}, func(int) error {
+		attempts++
+		return fail
+	}, func(int, time.Duration, error) { cancel() })
+	if !errors.Is(err, fail) || attempts != 1 {
+		t.Errorf("cancelled during backoff: err = %v after %d attempts, want boom after 1", err, attempts)
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) != nil")
+	}
+	err := Permanent(os.ErrNotExist)
+	if !errors.Is(err, os.ErrNotExist) || err.Error() != os.ErrNotExist.Error() {
+		t.Errorf("Permanent hides the error it wraps: %v", err)
+	}
+	for _, err := range []error{err, context.Canceled, fmt.Errorf("x: %w", context.DeadlineExceeded), ErrNoLoader, ErrNoDelivery} {
+		if DefaultRetryable(err) {
+			t.Errorf("DefaultRetryable(%v) = true", err)
+		}
+	}
+	if !DefaultRetryable(os.ErrNotExist) {
+		t.Error("plain errors aren't retryable")
+	}
+}
+
+func TestParseRetryPolicy(t *testing.T) {
+	p, err := ParseRetryPolicy(map[string]string{
+		"retry_attempts":    "5",
+		"retry_backoff":     "10s",
+		"retry_max_backoff": "5m",
+		"retry_multiplier":  "1.5",
+		"retry_jitter":      "0.2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := RetryPolicy{MaxAttempts: 5, InitialBackoff: 10 * time.Second, MaxBackoff: 5 * time.Minute, Multiplier: 1.5, Jitter: 0.2}
+	if !reflect.DeepEqual(*p, want) {
+		t.Errorf("policy = %+v, want %+v", *p, want)
+	}
+
+	if p, err := ParseRetryPolicy(map[string]string{"retry_backoff": "10s"}); p != nil || err != nil {
+		t.Errorf("without retry_attempts: %+v, %v; want nil, nil", p, err)
+	}
+
+	for key, value := range map[string]string{
+		"retry_attempts":    "three",
+		"retry_backoff":     "10",
+		"retry_max_backoff": "later",
+		"retry_multiplier":  "0.5",
+		"retry_jitter":      "some",
+	} {
+		cfg := map[string]string{"retry_attempts": "3", key: value}
+		if _, err := ParseRetryPolicy(cfg); err == nil || !strings.Contains(err.Error(), key) {
+			t.Errorf("%s=%s: err = %v, want it to name %s", key, value, err, key)
+		}
+	}
+}
+
+func TestRetryPolicyValidation(t *testing.T) {
+	e, _ := newTestEngine(t, Config{})
+	job := cronJob("daily", "0 0 9 * * *")
+	job.Retry = &RetryPolicy{MaxAttempts: 3, Multiplier: 0.5}
+	if err := e.AddCronJob(job); err == nil || !strings.Contains(err.Error(), "multiplier") {
+		t.Errorf("job policy: err = %v, want a multiplier error", err)
+	}
+
+	job.Retry = nil
+	job.Delivery = []DeliveryConfig{{"type": "archive", "retry_attempts": "3", "retry_multiplier": "-2"}}
+	e.RegisterDelivery("archive", nopDelivery{})
+	if err := e.AddCronJob(job); err == nil || !strings.Contains(err.Error(), "retry_multiplier") {
+		t.Errorf("delivery policy: err = %v, want a retry_multiplier error", err)
+	}
+	if n := len(e.ListJobs()); n != 0 {
+		t.Errorf("%d jobs scheduled with invalid policies", n)
+	}
+}
+
+// fileOutput writes report.txt to dir and fails if it already exists.
+type fileOutput struct{ dir string }
+
+func (o fileOutput) Generate(ctx context.Context, doc RenderedDoc, format string) (OutputFile, error) {
+	path := filepath.Join(o.dir, "report.txt")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return OutputFile{}, err
+	}
+	return OutputFile{Name: "report.txt", Path: path}, f.Close()
+}
+
+// flakyOutput fails the first n calls.
+type flakyOutput struct{ n *int }
+
+func (o flakyOutput) Generate(ctx context.Context, doc RenderedDoc, format string) (OutputFile, error) {
+	if *o.n > 0 {
+		*o.n--
+		return OutputFile{}, errors.New("disk hiccup")
+	}
+	return OutputFile{Name: "report.dat", Data: []byte("ok")}, nil
+}
+
+func TestRetryRemovesFailedOutputs(t *testing.T) {
+	e, _ := newTestEngine(t, Config{})
+	dir := t.TempDir()
+	failures := 2
+	e.RegisterLoader("none", nopLoader{})
+	e.RegisterOutput("file", fileOutput{dir: dir})
+	e.RegisterOutput("flaky", flakyOutput{n: &failures})
+
+	job := testJob("daily", ConcurrencyAllow)
+	job.DataSource["type"] = "none"
+	job.Outputs = []string{"file", "flaky"}
+	job.Retry = &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	run := waitRun(t, e, mustEnqueue(t, e, job), RunSucceeded)
+
+	if run.Attempts != 3 || len(run.Outputs) != 2 {
+		t.Errorf("run = %+v, want 3 attempts and 2 outputs", run)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("%d files left in the output directory, want 1", len(entries))
+	}
+}
@@ -0,0 +1,114 @@
+package cronyx
+
+import (
+	"context"
+	"time"
+)
+
+// Trigger records what started a run.
+type Trigger string
+
+const (
+	TriggerSchedule Trigger = "schedule" // fired by the cron scheduler
+	TriggerManual   Trigger = "manual"   // queued with Enqueue
+	TriggerTest     Trigger = "test"     // run synchronously by TestExecute
+)
+
+// RunStatus is the state of a run.
+type RunStatus string
+
+const (
+	RunRunning   RunStatus = "running"
+	RunSucceeded RunStatus = "succeeded"
+	RunFailed    RunStatus = "failed"
+	RunAbandoned RunStatus = "abandoned" // queued but dropped by Engine.Shutdown
+	RunSkipped   RunStatus = "skipped"   // not started because of ReportJob.Concurrency
+	RunCancelled RunStatus = "cancelled" // cancelled with CancelRun, CancelJob or on Shutdown
+	RunDropped   RunStatus = "dropped"   // trigger dropped by Config.Overflow
+)
+
+// JobRun is the record of one execution of a job.
+type JobRun struct {
+	ID             string                  `json:"id"`
+	JobID          string                  `json:"job_id"`
+	JobName        string                  `json:"job_name"`
+	Trigger        Trigger                 `json:"trigger"`
+	Status         RunStatus               `json:"status"`
+	StartedAt      time.Time               `json:"started_at"`
+	FinishedAt     time.Time               `json:"finished_at,omitempty"`
+	Attempts       int                     `json:"attempts"` // pipeline attempts, see ReportJob.Retry
+	StageDurations map[Stage]time.Duration `json:"stage_durations,omitempty"`
+	Error          string                  `json:"error,omitempty"`
+	// Outputs lists the generated files. File contents (OutputFile.Data)
+	// are not retained.
+	Outputs []OutputFile `json:"outputs,omitempty"`
+}
+
+// Duration is the wall time of the run, or zero while it is still running.
+func (r JobRun) Duration() time.Duration {
+	if r.FinishedAt.IsZero() {
+		return 0
+	}
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// RunQuery filters runs. Zero fields match everything.
+type RunQuery struct {
+	JobID  string
+	Status RunStatus
+	Since  time.Time // runs started at or after Since
+	Until  time.Time // runs started before Until
+	Limit  int       // maximum number of runs returned, newest first
+}
+
+// Match reports whether run satisfies the query filters (Limit aside).
+func (q RunQuery) Match(run JobRun) bool {
+	if q.JobID != "" && run.JobID != q.JobID {
+		return false
+	}
+	if q.Status != "" && run.Status != q.Status {
+		return false
+	}
+	if !q.Since.IsZero() && run.StartedAt.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !run.StartedAt.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// RunStore persists run history.
+type RunStore interface {
+	// SaveRun inserts the run, or replaces the stored run with the same ID.
+	SaveRun(ctx context.Context, run JobRun) error
+	// GetRun returns the run with the given ID or ErrRunNotFound.
+	GetRun(ctx context.Context, id string) (JobRun, error)
+	// ListRuns returns the runs matching q, newest first.
+	ListRuns(ctx context.Context, q RunQuery) ([]JobRun, error)
+}
+
+// RunInfo describes the run a pipeline stage is working for. The engine
+// attaches it to the context passed to loaders, renderers, output
+// generators and delivery adapters.
+type RunInfo struct {
+	RunID     string
+	Job       ReportJob
+	Trigger   Trigger
+	StartedAt time.Time
+	// Report is the rendered document. It is set for the delivery stage.
+	Report *RenderedDoc
+}
+
+type runInfoKey struct{}
+
+// ContextWithRunInfo returns a copy of ctx carrying info.
+func ContextWithRunInfo(ctx context.Context, info RunInfo) context.Context {
+	return context.WithValue(ctx, runInfoKey{}, info)
+}
+
+// RunInfoFromContext returns the RunInfo attached by the engine, if any.
+func RunInfoFromContext(ctx context.Context) (RunInfo, bool) {
+	info, ok := ctx.Value(runInfoKey{}).(RunInfo)
+	return info, ok
+}
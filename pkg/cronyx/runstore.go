@@ -0,0 +1,83 @@
+package cronyx
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// DefaultRunHistory is the number of runs kept by the default in-memory store.
+const DefaultRunHistory = 1000
+
+// MemoryRunStore keeps the most recent runs in memory.
+type MemoryRunStore struct {
+	mu    sync.RWMutex
+	limit int
+	runs  map[string]JobRun
+	order []string // run IDs, oldest first
+}
+
+// NewMemoryRunStore creates a store holding at most limit runs; older runs
+// are evicted first. A limit <= 0 keeps every run.
+func NewMemoryRunStore(limit int) *MemoryRunStore {
+	return &MemoryRunStore{
+		limit: limit,
+		runs:  map[string]JobRun{},
+	}
+}
+
+func (s *MemoryRunStore) SaveRun(ctx context.Context, run JobRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.runs[run.ID]; !ok {
+		s.order = append(s.order, run.ID)
+	}
+	s.runs[run.ID] = run
+
+	for s.limit > 0 && len(s.order) > s.limit {
+		delete(s.runs, s.order[0])
+		s.order = s.order[1:]
+	}
+	return nil
+}
+
+func (s *MemoryRunStore) GetRun(ctx context.Context, id string) (JobRun, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	run, ok := s.runs[id]
+	if !ok {
+		return JobRun{}, ErrRunNotFound
+	}
+	return run, nil
+}
+
+func (s *MemoryRunStore) ListRuns(ctx context.Context, q RunQuery) ([]JobRun, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	runs := make([]JobRun, 0, len(s.runs))
+	for _, run := range s.runs {
+		runs = append(runs, run)
+	}
+	return FilterRuns(runs, q), nil
+}
+
+// FilterRuns applies q to runs and returns the matches newest first. It is
+// meant for RunStore implementations that filter in memory.
+func FilterRuns(runs []JobRun, q RunQuery) []JobRun {
+	var out []JobRun
+	for _, run := range runs {
+		if q.Match(run) {
+			out = append(out, run)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].StartedAt.After(out[j].StartedAt)
+	})
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[:q.Limit]
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package cronyx
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryRunStore(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewMemoryRunStore(3)
+	for i, id := range []string{"a", "b", "c", "d"} {
+		s.SaveRun(ctx, JobRun{ID: id, JobID: "daily", Status: RunSucceeded, StartedAt: start.Add(time.Duration(i) * time.Hour)})
+	}
+	// updating a run doesn't move it in the eviction order
+	s.SaveRun(ctx, JobRun{ID: "b", JobID: "weekly", Status: RunFailed, StartedAt: start.Add(time.Hour)})
+
+	if _, err := s.GetRun(ctx, "a"); err != ErrRunNotFound {
+		t.Errorf("GetRun(a): err = %v, want the oldest run evicted", err)
+	}
+	tests := []struct {
+		name string
+		q    RunQuery
+		want []string
+	}{
+		{"all, newest first", RunQuery{}, []string{"d", "c", "b"}},
+		{"job", RunQuery{JobID: "daily"}, []string{"d", "c"}},
+		{"status", RunQuery{Status: RunFailed}, []string{"b"}},
+		{"since and until", RunQuery{Since: start.Add(time.Hour), Until: start.Add(3 * time.Hour)}, []string{"c", "b"}},
+		{"limit", RunQuery{Limit: 1}, []string{"d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs, err := s.ListRuns(ctx, tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range runs {
+				got = append(got, r.ID)
+			}
+			if !equalStrings(got, tt.want) {
+				t.Errorf("ListRuns = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveRunStoresSnapshot(t *testing.T) {
+	e := NewEngineWithConfig(&Config{})
+	run := &JobRun{ID: "run-1", StageDurations: map[Stage]time.Duration{StageLoad: time.Second}, Outputs: []OutputFile{{Name: "a.csv"}}}
+	e.saveRun(*run)
+
+	// the engine keeps timing stages of the run after saving it
+	run.StageDurations[StageLoad] = time.Minute
+	run.StageDurations[StageRender] = time.Minute
+	run.Outputs[0].Name = "b.csv"
+
+	got, err := e.GetRun(context.Background(), "run-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.StageDurations) != 1 || got.StageDurations[StageLoad] != time.Second || got.Outputs[0].Name != "a.csv" {
+		t.Errorf("stored run changed with the live one: %+v", got)
+	}
+}
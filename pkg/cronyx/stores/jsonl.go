@@ -0,0 +1,205 @@
+package stores
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+// minCompactLines is the number of superseded lines the file may hold
+// before SaveRun compacts it.
+const minCompactLines = 1000
+
+// JSONLRunStore persists runs to a JSON lines file. Every save appends the
+// full run record; when a run is saved more than once the last line wins.
+// The file is read once on open and queries are served from memory.
+//
+// The file is compacted on open and whenever superseded lines outnumber
+// the runs (and at least minCompactLines of them have piled up). A final
+// line left half-written by a crash is dropped on open.
+type JSONLRunStore struct {
+	mu    sync.RWMutex
+	path  string
+	f     *os.File
+	runs  map[string]cronyx.JobRun
+	lines int // records in the file, including superseded ones
+}
+
+// OpenJSONLRunStore opens (or creates) the run history file at path.
+func OpenJSONLRunStore(path string) (*JSONLRunStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create run store directory: %w", err)
+	}
+
+	runs, lines, err := readRuns(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open run store: %w", err)
+	}
+	s := &JSONLRunStore{path: path, f: f, runs: runs, lines: lines}
+	if lines > len(runs) {
+		if err := s.Compact(); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// readRuns loads the runs in the file and counts its records. A final line
+// that is cut short or doesn't parse is what a crash during SaveRun leaves
+// behind; it is truncated away. Bad lines elsewhere are an error.
+func readRuns(path string) (map[string]cronyx.JobRun, int, error) {
+	runs := map[string]cronyx.JobRun{}
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return runs, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open run store: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	var offset int64
+	lines := 0
+	for line := 1; ; line++ {
+		b, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, 0, fmt.Errorf("failed to read run store: %w", err)
+		}
+		if len(b) == 0 {
+			break
+		}
+		complete := b[len(b)-1] == '\n'
+		var run cronyx.JobRun
+		if len(bytes.TrimSpace(b)) > 0 {
+			if perr := json.Unmarshal(b, &run); perr != nil || !complete {
+				if _, peek := r.Peek(1); peek == io.EOF {
+					if err := f.Truncate(offset); err != nil {
+						return nil, 0, fmt.Errorf("failed to truncate run store: %w", err)
+					}
+					break
+				}
+				return nil, 0, fmt.Errorf("run store %s line %d: %w", path, line, perr)
+			}
+			runs[run.ID] = run
+			lines++
+		}
+		offset += int64(len(b))
+		if err == io.EOF {
+			break
+		}
+	}
+	return runs, lines, nil
+}
+
+func (s *JSONLRunStore) SaveRun(ctx context.Context, run cronyx.JobRun) error {
+	b, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("failed to write run: %w", err)
+	}
+	s.runs[run.ID] = run
+	s.lines++
+	if stale := s.lines - len(s.runs); stale >= minCompactLines && stale > len(s.runs) {
+		return s.compactLocked()
+	}
+	return nil
+}
+
+func (s *JSONLRunStore) GetRun(ctx context.Context, id string) (cronyx.JobRun, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	run, ok := s.runs[id]
+	if !ok {
+		return cronyx.JobRun{}, cronyx.ErrRunNotFound
+	}
+	return run, nil
+}
+
+func (s *JSONLRunStore) ListRuns(ctx context.Context, q cronyx.RunQuery) ([]cronyx.JobRun, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return cronyx.FilterRuns(s.allRuns(), q), nil
+}
+
+// Compact rewrites the file with a single line per run, dropping
+// superseded records.
+func (s *JSONLRunStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.compactLocked()
+}
+
+func (s *JSONLRunStore) compactLocked() error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to compact run store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, run := range cronyx.FilterRuns(s.allRuns(), cronyx.RunQuery{}) {
+		if err := enc.Encode(run); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to compact run store: %w", err)
+	}
+
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to reopen run store: %w", err)
+	}
+	s.f.Close()
+	s.f = f
+	s.lines = len(s.runs)
+	return nil
+}
+
+func (s *JSONLRunStore) allRuns() []cronyx.JobRun {
+	runs := make([]cronyx.JobRun, 0, len(s.runs))
+	for _, run := range s.runs {
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// Close closes the underlying file.
+func (s *JSONLRunStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
@@ -0,0 +1,149 @@
+package stores
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nyxox-debug/Cronyx/pkg/cronyx"
+)
+
+func openStore(t *testing.T, path string) *JSONLRunStore {
+	t.Helper()
+	s, err := OpenJSONLRunStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func save(t *testing.T, s *JSONLRunStore, runs ...cronyx.JobRun) {
+	t.Helper()
+	for _, run := range runs {
+		if err := s.SaveRun(context.Background(), run); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Count(b, []byte("\n"))
+}
+
+func TestJSONLRunStoreReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs", "runs.jsonl")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s := openStore(t, path)
+	save(t, s,
+		cronyx.JobRun{ID: "a", JobID: "daily", Status: cronyx.RunRunning, StartedAt: start},
+		cronyx.JobRun{ID: "b", JobID: "weekly", Status: cronyx.RunFailed, StartedAt: start.Add(time.Hour)},
+		cronyx.JobRun{ID: "a", JobID: "daily", Status: cronyx.RunSucceeded, StartedAt: start,
+			StageDurations: map[cronyx.Stage]time.Duration{cronyx.StageLoad: time.Second}},
+	)
+	s.Close()
+
+	s = openStore(t, path)
+	run, err := s.GetRun(context.Background(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != cronyx.RunSucceeded || run.StageDurations[cronyx.StageLoad] != time.Second {
+		t.Errorf("run a = %+v, want the last record", run)
+	}
+	if _, err := s.GetRun(context.Background(), "nope"); !errors.Is(err, cronyx.ErrRunNotFound) {
+		t.Errorf("GetRun(nope): err = %v, want ErrRunNotFound", err)
+	}
+	runs, _ := s.ListRuns(context.Background(), cronyx.RunQuery{})
+	if len(runs) != 2 || runs[0].ID != "b" {
+		t.Errorf("ListRuns = %+v, want b then a", runs)
+	}
+	// the superseded record of a was compacted away on open
+	if n := countLines(t, path); n != 2 {
+		t.Errorf("file has %d lines after reopening, want 2", n)
+	}
+}
+
+func TestJSONLRunStoreTornFinalLine(t *testing.T) {
+	tests := []struct {
+		name string
+		tail string
+	}{
+		{"cut short", `{"id":"c","job_id":"da`},
+		{"no newline", `{"id":"c","job_id":"daily"}`},
+		{"garbage", "\x00\x00\x00\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "runs.jsonl")
+			good := `{"id":"a","job_id":"daily","status":"succeeded"}` + "\n" +
+				`{"id":"b","job_id":"daily","status":"failed"}` + "\n"
+			if err := os.WriteFile(path, []byte(good+tt.tail), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			s := openStore(t, path)
+			if runs, _ := s.ListRuns(context.Background(), cronyx.RunQuery{}); len(runs) != 2 {
+				t.Errorf("loaded %d runs, want 2", len(runs))
+			}
+			save(t, s, cronyx.JobRun{ID: "d", JobID: "daily"})
+			s.Close()
+
+			// the new record starts on a line of its own
+			s = openStore(t, path)
+			if _, err := s.GetRun(context.Background(), "d"); err != nil {
+				t.Errorf("GetRun(d) after reopening: %v", err)
+			}
+		})
+	}
+}
+
+func TestJSONLRunStoreCorruptLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.jsonl")
+	data := `{"id":"a"}` + "\n" + "not json\n" + `{"id":"b"}` + "\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := OpenJSONLRunStore(path)
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("err = %v, want an error for line 2", err)
+	}
+}
+
+func TestJSONLRunStoreCompactsAfterSaves(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.jsonl")
+	s := openStore(t, path)
+	save(t, s, cronyx.JobRun{ID: "a"}, cronyx.JobRun{ID: "b"})
+	for i := 0; i < minCompactLines+10; i++ {
+		save(t, s, cronyx.JobRun{ID: "a", Attempts: i})
+	}
+	if n := countLines(t, path); n > minCompactLines {
+		t.Errorf("file has %d lines, want it compacted", n)
+	}
+	run, _ := s.GetRun(context.Background(), "a")
+	if run.Attempts != minCompactLines+9 {
+		t.Errorf("Attempts = %d, want the last save", run.Attempts)
+	}
+
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if n := countLines(t, path); n != 2 {
+		t.Errorf("file has %d lines after Compact, want 2", n)
+	}
+	save(t, s, cronyx.JobRun{ID: "c"})
+	if n := countLines(t, path); n != 3 {
+		t.Errorf("file has %d lines after saving to the compacted file, want 3", n)
+	}
+}